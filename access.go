@@ -0,0 +1,122 @@
+package luar
+
+import (
+	"reflect"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// AccessOp is an operation that Lua code performs on a Go value.
+type AccessOp int
+
+const (
+	// AccessRead is reading a struct field or a map, array or slice element.
+	AccessRead AccessOp = iota
+	// AccessWrite is assigning a struct field, a map, array or slice element,
+	// or a pointer value.
+	AccessWrite
+	// AccessCall is calling a function or method.
+	AccessCall
+	// AccessIterate is creating an iterator over a map, array or slice.
+	AccessIterate
+	// AccessAppend is appending to a slice.
+	AccessAppend
+	// AccessClose is closing a channel.
+	AccessClose
+	// AccessSend is sending a value on a channel.
+	AccessSend
+	// AccessReceive is receiving a value from a channel.
+	AccessReceive
+)
+
+var accessOpNames = [...]string{
+	AccessRead:    "read",
+	AccessWrite:   "write",
+	AccessCall:    "call",
+	AccessIterate: "iterate",
+	AccessAppend:  "append",
+	AccessClose:   "close",
+	AccessSend:    "send",
+	AccessReceive: "receive",
+}
+
+func (op AccessOp) String() string {
+	if op >= 0 && int(op) < len(accessOpNames) {
+		return accessOpNames[op]
+	}
+	return "unknown"
+}
+
+// AccessContext describes an access to a Go value from Lua. It is passed to
+// Config.AccessPolicy.
+type AccessContext struct {
+	L *lua.LState
+	// The type of the accessed value. For methods, this is the receiver type.
+	Type reflect.Type
+	// The Go name of the accessed field or method. Empty for functions and for
+	// elements of arrays, channels, maps and slices.
+	Member string
+	Op     AccessOp
+}
+
+type accessKey struct {
+	Type   reflect.Type
+	Member string
+	Op     AccessOp
+}
+
+// checkAccess raises the error of the access policy of L's configuration as a
+// Lua error if it denies the given access. The error can be retrieved using
+// WrapError.
+func checkAccess(L *lua.LState, t reflect.Type, member string, op AccessOp) {
+	GetConfig(L).checkAccess(L, t, member, op)
+}
+
+func (c *Config) checkAccess(L *lua.LState, t reflect.Type, member string, op AccessOp) {
+	if c.settings().AccessPolicy == nil {
+		// Checked here so that the check is inlined on the hot paths.
+		return
+	}
+	if err := c.accessError(L, t, member, op); err != nil {
+		raiseMessage(L, err, err.Error())
+	}
+}
+
+// accessError returns the error of the access policy of L's configuration if
+// it denies the given access, or nil.
+func accessError(L *lua.LState, t reflect.Type, member string, op AccessOp) error {
+	return GetConfig(L).accessError(L, t, member, op)
+}
+
+func (c *Config) accessError(L *lua.LState, t reflect.Type, member string, op AccessOp) error {
+	settings := c.settings()
+	if settings.AccessPolicy == nil {
+		return nil
+	}
+
+	key := accessKey{
+		Type:   t,
+		Member: member,
+		Op:     op,
+	}
+	if settings.CacheAccessPolicy {
+		if _, ok := c.allowed[key]; ok {
+			return nil
+		}
+	}
+
+	err := settings.AccessPolicy(AccessContext{
+		L:      L,
+		Type:   t,
+		Member: member,
+		Op:     op,
+	})
+	if err != nil {
+		return err
+	}
+
+	if settings.CacheAccessPolicy {
+		c.allowed[key] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package luar
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_access_policy(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	var denied []string
+	config := GetConfig(L)
+	config.AccessPolicy = func(ctx AccessContext) error {
+		if ctx.L != L {
+			t.Fatal("unexpected LState")
+		}
+		switch {
+		case ctx.Member == "Age" && ctx.Op == AccessWrite,
+			ctx.Member == "IncreaseAge",
+			ctx.Type.Kind() == reflect.Slice && ctx.Op == AccessAppend,
+			ctx.Type.Kind() == reflect.Map && ctx.Op == AccessWrite,
+			ctx.Type.Kind() == reflect.Chan && ctx.Op == AccessClose:
+			denied = append(denied, ctx.Op.String())
+			return errors.New("access denied")
+		}
+		return nil
+	}
+
+	p := &StructTestPerson{Name: "Tim", Age: 30}
+
+	L.SetGlobal("p", New(L, p))
+	L.SetGlobal("s", New(L, []string{"a"}))
+	L.SetGlobal("m", New(L, map[string]int{"a": 1}))
+	L.SetGlobal("ch", New(L, make(chan int)))
+
+	testReturn(t, L, `return p.Age, p:Hello()`, "30", "Hello, Tim")
+	testReturn(t, L, `p.Name = "John"; return p.Name`, "John")
+	testError(t, L, `p.Age = 31`, "access denied")
+	testError(t, L, `p:IncreaseAge()`, "access denied")
+	testReturn(t, L, `return s[1], m.a`, "a", "1")
+	testError(t, L, `s:append("b")`, "access denied")
+	testError(t, L, `m.b = 2`, "access denied")
+	testError(t, L, `ch:close()`, "access denied")
+
+	if p.Age != 30 {
+		t.Fatalf("expecting Age to be unchanged, got %d", p.Age)
+	}
+	if len(denied) != 5 {
+		t.Fatalf("expecting 5 denied accesses, got %v", denied)
+	}
+}
+
+func Test_access_policy_cache(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	calls := 0
+	config := GetConfig(L)
+	config.CacheAccessPolicy = true
+	config.AccessPolicy = func(ctx AccessContext) error {
+		calls++
+		if ctx.Member == "Age" {
+			return errors.New("access denied")
+		}
+		return nil
+	}
+
+	L.SetGlobal("p", New(L, &StructTestPerson{Name: "Tim"}))
+
+	testReturn(t, L, `return p.Name, p.Name, p.name`, "Tim", "Tim", "Tim")
+	testError(t, L, `return p.Age`, "access denied")
+	testError(t, L, `return p.Age`, "access denied")
+
+	if calls != 3 {
+		t.Fatalf("expecting 3 policy calls, got %d", calls)
+	}
+}
+
+var errTestAccessDenied = errors.New("access denied")
+
+func Test_access_policy_error(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	GetConfig(L).AccessPolicy = func(ctx AccessContext) error {
+		if ctx.Type.Kind() == reflect.Map && ctx.Op == AccessRead {
+			return fmt.Errorf("reading %s: %w", ctx.Type, errTestAccessDenied)
+		}
+		return nil
+	}
+
+	L.SetGlobal("m", New(L, MapAlias{"a": 1, "b": 2}))
+
+	// The denial does not depend on whether the key exists.
+	for _, script := range []string{`return m.a`, `return m.c`} {
+		err := WrapError(L.DoString(script))
+		if !errors.Is(err, errTestAccessDenied) {
+			t.Fatalf("%s: expecting the error of the access policy, got %v", script, err)
+		}
+	}
+	testReturn(t, L, `return m:Y()`, "2")
+
+	// Callers that do not use WrapError get the message of the denial.
+	err := L.DoString(`return m.a`)
+	if s := err.Error(); !strings.HasPrefix(s, "<string>:1: reading luar.MapAlias: access denied") {
+		t.Fatalf("unexpected error message %s", s)
+	}
+}
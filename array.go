@@ -13,6 +13,7 @@ func arrayIndex(L *lua.LState) int {
 
 	switch converted := key.(type) {
 	case lua.LNumber:
+		checkAccess(L, ref.Type(), "", AccessRead)
 		index := int(converted)
 		if index < 1 || index > ref.Len() {
 			L.ArgError(2, "index out of range")
@@ -58,6 +59,7 @@ func arrayNewIndex(L *lua.LState) int {
 	if index < 1 || index > ref.Len() {
 		L.ArgError(2, "index out of range")
 	}
+	checkAccess(L, ref.Type(), "", AccessWrite)
 	val := lValueToReflect(L, value, ref.Type().Elem(), nil)
 	if !val.IsValid() {
 		L.ArgError(3, "invalid value")
@@ -76,6 +78,7 @@ func arrayLen(L *lua.LState) int {
 func arrayCall(L *lua.LState) int {
 	ref, opts, _, _ := check(L, 1, reflect.Array)
 	ref = reflect.Indirect(ref)
+	checkAccess(L, ref.Type(), "", AccessIterate)
 
 	i := 0
 	fn := func(L *lua.LState) int {
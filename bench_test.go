@@ -0,0 +1,120 @@
+package luar
+
+import (
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type BenchTestInner struct {
+	Level int
+}
+
+type BenchTestClaim struct {
+	ID     int
+	Amount float64
+	BenchTestInner
+}
+
+func (c BenchTestClaim) Total() float64 {
+	return c.Amount
+}
+
+func (c *BenchTestClaim) Adjust(delta float64) {
+	c.Amount += delta
+}
+
+// benchmarkScript runs script b.N times in a Lua loop, in which the globals c
+// and fn are available as locals.
+func benchmarkScript(b *testing.B, L *lua.LState, script string) {
+	fn, err := L.LoadString(`
+		local c, fn = c, fn
+		return function(n)
+			for i = 1, n do
+				` + script + `
+			end
+		end
+	`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	L.Push(fn)
+	if err := L.PCall(0, 1, nil); err != nil {
+		b.Fatal(err)
+	}
+	loop := L.Get(-1)
+	L.Pop(1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	if err := L.CallByParam(lua.P{Fn: loop, Protect: true}, lua.LNumber(b.N)); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func benchmarkStruct(b *testing.B, script string) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.SetGlobal("c", New(L, &BenchTestClaim{ID: 1, Amount: 2}))
+	benchmarkScript(b, L, script)
+}
+
+func BenchmarkStructFieldRead(b *testing.B) {
+	benchmarkStruct(b, `local _ = c.Amount`)
+}
+
+func BenchmarkStructEmbeddedFieldRead(b *testing.B) {
+	benchmarkStruct(b, `local _ = c.Level`)
+}
+
+func BenchmarkStructFieldWrite(b *testing.B) {
+	benchmarkStruct(b, `c.ID = i`)
+}
+
+func BenchmarkStructMethodLookup(b *testing.B) {
+	benchmarkStruct(b, `local _ = c.Total`)
+}
+
+func BenchmarkStructPtrMethodLookup(b *testing.B) {
+	benchmarkStruct(b, `local _ = c.Adjust`)
+}
+
+func BenchmarkStructMissingMember(b *testing.B) {
+	benchmarkStruct(b, `local _ = c.Missing`)
+}
+
+// benchmarkFunc benchmarks calls of fn. If fastPaths is false, fn is called
+// through reflection even if it has a fast path.
+func benchmarkFunc(b *testing.B, fn interface{}, call string, fastPaths bool) {
+	L := lua.NewState()
+	defer L.Close()
+
+	GetConfig(L).disableFastPaths = !fastPaths
+	L.SetGlobal("fn", New(L, fn))
+	benchmarkScript(b, L, `local _ = `+call)
+}
+
+func benchPrefix(s string) bool {
+	return len(s) > 2 && s[:2] == "CL"
+}
+
+func benchAdd(a, b float64) float64 {
+	return a + b
+}
+
+func BenchmarkFuncStringBool(b *testing.B) {
+	benchmarkFunc(b, benchPrefix, `fn("CL12")`, true)
+}
+
+func BenchmarkFuncStringBoolRegular(b *testing.B) {
+	benchmarkFunc(b, benchPrefix, `fn("CL12")`, false)
+}
+
+func BenchmarkFuncFloatAdd(b *testing.B) {
+	benchmarkFunc(b, benchAdd, `fn(i, 2)`, true)
+}
+
+func BenchmarkFuncFloatAddRegular(b *testing.B) {
+	benchmarkFunc(b, benchAdd, `fn(i, 2)`, false)
+}
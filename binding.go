@@ -0,0 +1,379 @@
+package luar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// Binding configures how the fields and methods of a Go type are exposed to
+// Lua, for types whose declaration cannot be changed (e.g. to add luar tags).
+// It is created by Config.BindType or Bind.
+//
+// Hide, As and ReadOnly apply to the field or method that was last selected
+// by Field or Method. Bindings apply to the metatables that are built
+// afterwards; existing metatables can be rebuilt with Config.Invalidate.
+//
+// Example:
+//  Bind[vendor.Claim](GetConfig(L)).
+//    Field("InternalID").Hide().
+//    Field("Amt").As("amount").ReadOnly().
+//    Method("Close").Hide()
+type Binding struct {
+	t       reflect.Type
+	binding *typeBinding
+	member  *memberBinding
+	kind    string
+}
+
+// typeBinding holds the bindings of the fields and methods of a type, by Go
+// name.
+type typeBinding struct {
+	fields  map[string]*memberBinding
+	methods map[string]*memberBinding
+}
+
+type memberBinding struct {
+	// The index of the field in the bound type. nil for methods.
+	Index []int
+	// The Lua names of the member. nil if the names are generated by
+	// Config.FieldNames or Config.MethodNames.
+	Names    []string
+	Hidden   bool
+	ReadOnly bool
+}
+
+// BindType returns the Binding of the type t. Pointer types are bound as
+// their element type. Calling BindType again for the same type returns a
+// Binding that extends the existing one.
+func (c *Config) BindType(t reflect.Type) *Binding {
+	c.mustNotBeFrozen("BindType")
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if c.bindings == nil {
+		c.bindings = make(map[reflect.Type]*typeBinding)
+	}
+	binding := c.bindings[t]
+	if binding == nil {
+		binding = &typeBinding{
+			fields:  make(map[string]*memberBinding),
+			methods: make(map[string]*memberBinding),
+		}
+		c.bindings[t] = binding
+	}
+	return &Binding{
+		t:       t,
+		binding: binding,
+	}
+}
+
+// Field selects the field of the bound type with the given Go name. Fields of
+// embedded structs can be selected by their promoted name. Field panics if the
+// bound type has no such field.
+func (b *Binding) Field(name string) *Binding {
+	if err := b.selectField(name); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (b *Binding) selectField(name string) error {
+	if b.t.Kind() != reflect.Struct {
+		return fmt.Errorf("luar: cannot bind field %s of non-struct type %s", name, b.t)
+	}
+	field, ok := b.t.FieldByName(name)
+	if !ok || (field.PkgPath != "" && !field.Anonymous) {
+		return fmt.Errorf("luar: type %s has no exported field %s", b.t, name)
+	}
+	member := b.binding.fields[name]
+	if member == nil {
+		member = &memberBinding{
+			Index: field.Index,
+		}
+		b.binding.fields[name] = member
+	}
+	b.member = member
+	b.kind = "field"
+	return nil
+}
+
+// Method selects the method of the bound type, or of a pointer to it, with the
+// given name. Method panics if there is no such method.
+func (b *Binding) Method(name string) *Binding {
+	if err := b.selectMethod(name); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (b *Binding) selectMethod(name string) error {
+	t := b.t
+	if t.Kind() != reflect.Interface {
+		t = reflect.PtrTo(t)
+	}
+	if _, ok := t.MethodByName(name); !ok {
+		return fmt.Errorf("luar: type %s has no exported method %s", b.t, name)
+	}
+	member := b.binding.methods[name]
+	if member == nil {
+		member = &memberBinding{}
+		b.binding.methods[name] = member
+	}
+	b.member = member
+	b.kind = "method"
+	return nil
+}
+
+func (b *Binding) selected(op string) *memberBinding {
+	if b.member == nil {
+		panic("luar: " + op + " called before Field or Method")
+	}
+	return b.member
+}
+
+// Hide makes the selected field or method inaccessible from Lua. Hiding an
+// embedded struct also hides its promoted fields.
+func (b *Binding) Hide() *Binding {
+	b.selected("Hide").Hidden = true
+	return b
+}
+
+// As sets the Lua names of the selected field or method, replacing the names
+// generated by Config.FieldNames or Config.MethodNames.
+func (b *Binding) As(names ...string) *Binding {
+	b.selected("As").Names = append([]string(nil), names...)
+	return b
+}
+
+// ReadOnly prevents Lua code from assigning the selected field. Structs,
+// arrays, pointers, slices and maps read from the field are immutable (see
+// ReflectOptions.Immutable), so they cannot be modified either. ReadOnly panics
+// if a method is selected.
+func (b *Binding) ReadOnly() *Binding {
+	member := b.selected("ReadOnly")
+	if b.kind != "field" {
+		panic("luar: ReadOnly called on a method")
+	}
+	member.ReadOnly = true
+	return b
+}
+
+// fieldBinding returns the binding of the field of the struct type t with the
+// given index, or nil. The bindings of embedded structs apply to their
+// promoted fields; the binding of the outermost type takes precedence.
+func (c *Config) fieldBinding(t reflect.Type, index []int) *memberBinding {
+	if len(c.bindings) == 0 {
+		return nil
+	}
+	for k := range index {
+		if binding := c.bindings[t]; binding != nil {
+			field := t.FieldByIndex(index[k:])
+			if member := binding.fields[field.Name]; member != nil && indexEqual(member.Index, index[k:]) {
+				return member
+			}
+		}
+		t = t.Field(index[k]).Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+	return nil
+}
+
+func indexEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// methodBinding returns the binding of the method with the given name of t, or
+// nil.
+func (c *Config) methodBinding(t reflect.Type, name string) *memberBinding {
+	if len(c.bindings) == 0 {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if binding := c.bindings[t]; binding != nil {
+		return binding.methods[name]
+	}
+	return nil
+}
+
+// fieldNames returns the Lua names of a field of the struct type t. nil is
+// returned for inaccessible fields.
+func (c *Config) fieldNames(t reflect.Type, field *fieldDescriptor) []string {
+	if member := c.fieldBinding(t, field.Index); member != nil {
+		if member.Hidden {
+			return nil
+		}
+		if member.Names != nil {
+			return member.Names
+		}
+	}
+	fieldNames := c.settings().FieldNames
+	if fieldNames == nil {
+		return field.Names
+	}
+	return fieldNames(field.Owner, field.Field)
+}
+
+// methodNames returns the Lua names of a method of t. nil is returned for
+// inaccessible methods.
+func (c *Config) methodNames(t reflect.Type, method *methodDescriptor) []string {
+	if member := c.methodBinding(t, method.Method.Name); member != nil {
+		if member.Hidden {
+			return nil
+		}
+		if member.Names != nil {
+			return member.Names
+		}
+	}
+	methodNames := c.settings().MethodNames
+	if methodNames == nil {
+		return method.Names
+	}
+	return methodNames(t, method.Method)
+}
+
+// isReadOnlyField returns true if the field of the struct type t with the
+// given index was bound as read-only.
+func (c *Config) isReadOnlyField(t reflect.Type, index []int) bool {
+	member := c.fieldBinding(t, index)
+	return member != nil && member.ReadOnly
+}
+
+// BindingFile is the declarative form of bindings that is read by
+// Config.LoadBindings. It maps type names, as returned by reflect.Type's
+// String method (e.g. "vendor.Claim"), to their bindings. The types also have
+// yaml tags, so that bindings can be decoded from YAML with a YAML package and
+// added using Config.ApplyBindings.
+type BindingFile map[string]TypeBindingSpec
+
+// TypeBindingSpec is the declarative form of the Binding of a type.
+type TypeBindingSpec struct {
+	Fields  map[string]MemberBindingSpec `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Methods map[string]MemberBindingSpec `json:"methods,omitempty" yaml:"methods,omitempty"`
+}
+
+// MemberBindingSpec is the declarative form of the binding of a field or
+// method. The fields correspond to Binding's Hide, As and ReadOnly.
+type MemberBindingSpec struct {
+	Hide     bool     `json:"hide,omitempty" yaml:"hide,omitempty"`
+	As       []string `json:"as,omitempty" yaml:"as,omitempty"`
+	ReadOnly bool     `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+}
+
+// LoadBindings reads bindings in JSON form (see BindingFile) from r and adds
+// them to c, so that the exposure of types can be adjusted without rebuilding
+// the program. The types that may be bound are given as example values, like
+// for ExposeTypes. An error is returned for unknown types, fields, methods and
+// keys; in that case, no binding is added.
+//
+// Other formats can be supported by decoding them into a BindingFile and
+// calling Config.ApplyBindings.
+//
+// Example:
+//  {
+//    "vendor.Claim": {
+//      "fields": {
+//        "InternalID": {"hide": true},
+//        "Amt": {"as": ["amount"], "readOnly": true}
+//      },
+//      "methods": {
+//        "Close": {"hide": true}
+//      }
+//    }
+//  }
+//  ---
+//  f, err := os.Open("bindings.json")
+//  ...
+//  err = GetConfig(L).LoadBindings(f, vendor.Claim{})
+func (c *Config) LoadBindings(r io.Reader, types ...interface{}) error {
+	c.mustNotBeFrozen("LoadBindings")
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var file BindingFile
+	if err := dec.Decode(&file); err != nil {
+		return fmt.Errorf("luar: invalid bindings: %s", err)
+	}
+	return c.ApplyBindings(file, types...)
+}
+
+// ApplyBindings adds the bindings of file to c. The types that may be bound
+// are given as example values. An error is returned for unknown types, fields
+// and methods; in that case, no binding is added.
+func (c *Config) ApplyBindings(file BindingFile, types ...interface{}) error {
+	c.mustNotBeFrozen("ApplyBindings")
+	byName := make(map[string]reflect.Type, len(types))
+	for _, value := range types {
+		t := reflect.TypeOf(value)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		byName[t.String()] = t
+	}
+
+	// The bindings are validated on copies, so that nothing is added if an
+	// error occurs.
+	names := make([]string, 0, len(file))
+	for name := range file {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	scratch := &Config{}
+	for _, name := range names {
+		t, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("luar: cannot bind unknown type %s", name)
+		}
+		if err := file[name].apply(scratch.BindType(t)); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		file[name].apply(c.BindType(byName[name]))
+	}
+	return nil
+}
+
+func (spec TypeBindingSpec) apply(b *Binding) error {
+	for name, member := range spec.Fields {
+		if err := b.selectField(name); err != nil {
+			return err
+		}
+		member.apply(b)
+	}
+	for name, member := range spec.Methods {
+		if err := b.selectMethod(name); err != nil {
+			return err
+		}
+		if member.ReadOnly {
+			return fmt.Errorf("luar: method %s of type %s cannot be read-only", name, b.t)
+		}
+		member.apply(b)
+	}
+	return nil
+}
+
+func (spec MemberBindingSpec) apply(b *Binding) {
+	if spec.Hide {
+		b.Hide()
+	}
+	if spec.As != nil {
+		b.As(spec.As...)
+	}
+	if spec.ReadOnly {
+		b.ReadOnly()
+	}
+}
@@ -0,0 +1,168 @@
+package luar
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type BindingTestAudit struct {
+	CreatedBy string
+	Revision  int
+}
+
+type BindingTestClaim struct {
+	InternalID int
+	Amt        float64
+	Status     string
+	BindingTestAudit
+}
+
+func (c BindingTestClaim) Total() float64 {
+	return c.Amt * 2
+}
+
+func (c *BindingTestClaim) Close() {
+	c.Status = "closed"
+}
+
+func Test_binding(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.BindType(reflect.TypeOf(&BindingTestClaim{})).
+		Field("InternalID").Hide().
+		Field("Amt").As("amount").ReadOnly().
+		Field("CreatedBy").As("author").
+		Method("Close").Hide().
+		Method("Total").As("total_amount")
+	config.BindType(reflect.TypeOf(BindingTestAudit{})).
+		Field("Revision").ReadOnly()
+
+	L.SetGlobal("c", New(L, &BindingTestClaim{InternalID: 7, Amt: 10, Status: "open"}))
+
+	testReturn(t, L, `return c.InternalID, c.internalID, c.amount, c.Amt`, "nil", "nil", "10", "nil")
+	testReturn(t, L, `return c.author, c.CreatedBy, c.Revision`, "", "nil", "0")
+	testReturn(t, L, `return c:total_amount(), c.Total, c.Close`, "20", "nil", "nil")
+	testReturn(t, L, `c.Status = "closed"; c.author = "tim"; return c.Status, c.author`, "closed", "tim")
+	testError(t, L, `c.amount = 5`, "cannot set read-only field amount")
+	testError(t, L, `c.Revision = 5`, "cannot set read-only field Revision")
+	testError(t, L, `c.InternalID = 5`, "unknown field InternalID")
+}
+
+type BindingTestCase struct {
+	Audit    BindingTestAudit
+	Reviewer *BindingTestAudit
+	Scores   [3]int
+	Tags     []string
+	Notes    BindingTestAudit
+}
+
+func Test_binding_read_only_nested(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	GetConfig(L).BindType(reflect.TypeOf(BindingTestCase{})).
+		Field("Audit").ReadOnly().
+		Field("Reviewer").ReadOnly().
+		Field("Scores").ReadOnly().
+		Field("Tags").ReadOnly()
+
+	c := &BindingTestCase{
+		Audit:    BindingTestAudit{CreatedBy: "tim"},
+		Reviewer: &BindingTestAudit{CreatedBy: "ann"},
+		Scores:   [3]int{1, 2, 3},
+		Tags:     []string{"a"},
+	}
+	L.SetGlobal("c", New(L, c))
+
+	testReturn(t, L, `return c.Audit.CreatedBy, c.Reviewer.CreatedBy, c.Scores[2], c.Tags[1]`, "tim", "ann", "2", "a")
+	testError(t, L, `c.Audit.CreatedBy = "x"`, "invalid operation on immutable struct")
+	testError(t, L, `c.Reviewer.CreatedBy = "x"`, "invalid operation on immutable struct")
+	testError(t, L, `local r = c.Reviewer; r = r ^ c.Audit`, "invalid operation for immutable pointer")
+	testError(t, L, `c.Scores[1] = 5`, "invalid operation on immutable array")
+	testError(t, L, `c.Tags[1] = "b"`, "invalid operation on immutable slice")
+	testReturn(t, L, `c.Notes.CreatedBy = "x"; return c.Notes.CreatedBy`, "x")
+
+	if c.Audit.CreatedBy != "tim" || c.Reviewer.CreatedBy != "ann" || c.Scores[0] != 1 || c.Tags[0] != "a" {
+		t.Fatalf("read-only fields were modified: %+v", c)
+	}
+}
+
+func Test_binding_invalid(t *testing.T) {
+	config := newConfig()
+	tbl := []struct {
+		Fn      func()
+		Message string
+	}{
+		{func() { config.BindType(reflect.TypeOf(BindingTestClaim{})).Field("Missing") }, "has no exported field Missing"},
+		{func() { config.BindType(reflect.TypeOf(BindingTestClaim{})).Method("Missing") }, "has no exported method Missing"},
+		{func() { config.BindType(reflect.TypeOf(BindingTestClaim{})).Hide() }, "Hide called before Field or Method"},
+		{func() { config.BindType(reflect.TypeOf(BindingTestClaim{})).Method("Close").ReadOnly() }, "ReadOnly called on a method"},
+		{func() { config.BindType(reflect.TypeOf(0)).Field("Amt") }, "non-struct type int"},
+	}
+	for _, test := range tbl {
+		func() {
+			defer func() {
+				r := recover()
+				if r == nil {
+					t.Fatalf("expecting panic with %q", test.Message)
+				}
+				if s := fmt.Sprint(r); !strings.Contains(s, test.Message) {
+					t.Fatalf("expecting panic with %q, got %q", test.Message, s)
+				}
+			}()
+			test.Fn()
+		}()
+	}
+}
+
+func Test_binding_load(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	err := config.LoadBindings(strings.NewReader(`{
+		"luar.BindingTestClaim": {
+			"fields": {
+				"InternalID": {"hide": true},
+				"Amt": {"as": ["amount"], "readOnly": true}
+			},
+			"methods": {
+				"Close": {"hide": true}
+			}
+		}
+	}`), BindingTestClaim{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	L.SetGlobal("c", New(L, &BindingTestClaim{InternalID: 7, Amt: 10}))
+
+	testReturn(t, L, `return c.InternalID, c.amount, c.Close, c:Total()`, "nil", "10", "nil", "20")
+	testError(t, L, `c.amount = 5`, "cannot set read-only field amount")
+
+	invalid := []struct {
+		JSON    string
+		Message string
+	}{
+		{`{"luar.Other": {}}`, "cannot bind unknown type luar.Other"},
+		{`{"luar.BindingTestClaim": {"fields": {"Missing": {"hide": true}}}}`, "has no exported field Missing"},
+		{`{"luar.BindingTestClaim": {"methods": {"Close": {"readOnly": true}}}}`, "cannot be read-only"},
+		{`{"luar.BindingTestClaim": {"fields": {"Amt": {"hidden": true}}}}`, "invalid bindings"},
+	}
+	for _, test := range invalid {
+		config := newConfig()
+		err := config.LoadBindings(strings.NewReader(test.JSON), BindingTestClaim{})
+		if err == nil || !strings.Contains(err.Error(), test.Message) {
+			t.Fatalf("expecting error %q, got %v", test.Message, err)
+		}
+		if len(config.bindings) != 0 {
+			t.Fatalf("expecting no bindings after error, got %d", len(config.bindings))
+		}
+	}
+}
@@ -1,92 +1,118 @@
 package luar
 
 import (
-	"container/list"
 	"reflect"
 
 	"github.com/yuin/gopher-lua"
 )
 
 func addMethods(L *lua.LState, c *Config, vtype reflect.Type, tbl *lua.LTable, ptrReceiver bool) {
-	for i := 0; i < vtype.NumMethod(); i++ {
-		method := vtype.Method(i)
-		if method.PkgPath != "" {
+	desc := describeType(vtype)
+	for i := range desc.Methods {
+		method := &desc.Methods[i]
+		names := c.methodNames(vtype, method)
+		if len(names) == 0 {
 			continue
 		}
-		namesFn := c.MethodNames
-		if namesFn == nil {
-			namesFn = defaultMethodNames
-		}
-		fn := funcWrapper(L, method.Func, ptrReceiver, defaultReflectOptions())
-		for _, name := range namesFn(vtype, method) {
+		fn := methodWrapper(L, method.Method, ptrReceiver, c.methodKind(vtype, method) == MethodPure)
+		for _, name := range c.luaNames(names) {
+			if existing := tbl.RawGetString(name); existing != lua.LNil {
+				c.nameCollision(vtype, name, memberName(vtype, name, existing), method.Method.Name)
+				continue
+			}
 			tbl.RawSetString(name, fn)
 		}
 	}
 }
 
-func addFields(L *lua.LState, c *Config, vtype reflect.Type, tbl *lua.LTable) {
-	type element struct {
-		Type  reflect.Type
-		Index []int
+// interfaceMethod returns a copy of the interface method m that has its Type
+// and Func set like the methods of a concrete type, i.e. with the receiver as
+// the first argument.
+func interfaceMethod(t reflect.Type, m reflect.Method) reflect.Method {
+	in := []reflect.Type{t}
+	for i := 0; i < m.Type.NumIn(); i++ {
+		in = append(in, m.Type.In(i))
 	}
+	out := make([]reflect.Type, m.Type.NumOut())
+	for i := range out {
+		out[i] = m.Type.Out(i)
+	}
+	variadic := m.Type.IsVariadic()
+	index := m.Index
 
-	queue := list.New()
-	queue.PushFront(element{
-		Type: vtype,
+	m.Type = reflect.FuncOf(in, out, variadic)
+	m.Func = reflect.MakeFunc(m.Type, func(args []reflect.Value) []reflect.Value {
+		fn := args[0].Method(index)
+		if variadic {
+			return fn.CallSlice(args[1:])
+		}
+		return fn.Call(args[1:])
 	})
+	return m
+}
 
-	namesFn := c.FieldNames
-	if namesFn == nil {
-		namesFn = defaultFieldNames
+func addFields(L *lua.LState, c *Config, vtype reflect.Type, tbl *lua.LTable) {
+	// owner is the field that a name was assigned to
+	type owner struct {
+		Name  string
+		Depth int
 	}
 
-	for queue.Len() > 0 {
-		e := queue.Back()
-		elem := e.Value.(element)
-		vtype := elem.Type
-	fields:
-		for i := 0; i < vtype.NumField(); i++ {
-			field := vtype.Field(i)
-			if field.PkgPath != "" && !field.Anonymous {
-				continue
+	owners := make(map[string]owner)
+	// skipped holds the indexes of the embedded structs whose fields are not
+	// accessible
+	var skipped [][]int
+	desc := describeType(vtype)
+fields:
+	for i := range desc.Fields {
+		field := &desc.Fields[i]
+		for _, prefix := range skipped {
+			if indexHasPrefix(field.Index, prefix) {
+				continue fields
 			}
-			names := namesFn(vtype, field)
-			for _, key := range names {
-				if tbl.RawGetString(key) != lua.LNil {
-					continue fields
-				}
+		}
+
+		var names []string
+		shadowed := false
+		for _, key := range c.luaNames(c.fieldNames(vtype, field)) {
+			o, ok := owners[key]
+			switch {
+			case !ok:
+				names = append(names, key)
+			case o.Name == field.Field.Name && o.Depth < field.Depth():
+				// The field is shadowed by a field of an outer struct, like
+				// in Go.
+				shadowed = true
+			default:
+				c.nameCollision(vtype, key, o.Name, field.Field.Name)
 			}
-			index := make([]int, len(elem.Index)+1)
-			copy(index, elem.Index)
-			index[len(elem.Index)] = i
-
-			ud := L.NewUserData()
-			ud.Value = index
-			for _, key := range names {
-				tbl.RawSetString(key, ud)
+			if shadowed {
+				break
 			}
-			if field.Anonymous {
-				t := field.Type
-				if field.Type.Kind() != reflect.Struct {
-					if field.Type.Kind() != reflect.Ptr || field.Type.Elem().Kind() != reflect.Struct {
-						continue
-					}
-					t = field.Type.Elem()
-				}
-				index := make([]int, len(elem.Index)+1)
-				copy(index, elem.Index)
-				index[len(elem.Index)] = i
-				queue.PushFront(element{
-					Type:  t,
-					Index: index,
-				})
+		}
+		if shadowed || len(names) == 0 {
+			if field.Embedded {
+				skipped = append(skipped, field.Index)
 			}
+			continue
 		}
 
-		queue.Remove(e)
+		ud := L.NewUserData()
+		ud.Value = field.Index
+		for _, key := range names {
+			tbl.RawSetString(key, ud)
+			owners[key] = owner{
+				Name:  field.Field.Name,
+				Depth: field.Depth(),
+			}
+		}
 	}
 }
 
+func indexHasPrefix(index, prefix []int) bool {
+	return len(index) > len(prefix) && indexEqual(index[:len(prefix)], prefix)
+}
+
 func getMetatable(L *lua.LState, vtype reflect.Type) *lua.LTable {
 	config := GetConfig(L)
 
@@ -94,13 +120,25 @@ func getMetatable(L *lua.LState, vtype reflect.Type) *lua.LTable {
 		vtype = vtype.Elem()
 	}
 	if v := config.regular[vtype]; v != nil {
+		if config.isStale(v) {
+			config.rebuildMetatable(L, vtype, v)
+		}
 		return v
 	}
+	mt := newMetatable(L, config, vtype)
+	config.regular[vtype] = mt
+	return mt
+}
 
+// newMetatable builds the metatable of vtype, which must not be a pointer
+// type.
+func newMetatable(L *lua.LState, config *Config, vtype reflect.Type) *lua.LTable {
 	var (
 		mt         *lua.LTable
 		methods    *lua.LTable
 		ptrMethods *lua.LTable = L.CreateTable(0, 0)
+		fields     *lua.LTable
+		members    *structMembers
 	)
 
 	switch vtype.Kind() {
@@ -145,16 +183,27 @@ func getMetatable(L *lua.LState, vtype reflect.Type) *lua.LTable {
 		mt.RawSetString("__len", L.NewFunction(sliceLen))
 		mt.RawSetString("__call", L.NewFunction(sliceCall))
 		mt.RawSetString("__eq", L.NewFunction(sliceEq))
+	case reflect.Interface:
+		mt = L.CreateTable(0, 6)
+		methods = L.CreateTable(0, vtype.NumMethod())
+
+		mt.RawSetString("__index", L.NewFunction(ifaceIndex))
+		mt.RawSetString("__eq", L.NewFunction(eq))
 	case reflect.Struct:
 		mt = L.CreateTable(0, 10)
 		methods = L.CreateTable(0, 0)
 
-		fields := L.NewTable()
+		fields = L.NewTable()
 		addFields(L, config, vtype, fields)
 		mt.RawSetString("fields", fields)
 
-		mt.RawSetString("__index", L.NewFunction(structIndex))
-		mt.RawSetString("__newindex", L.NewFunction(structNewIndex))
+		members = &structMembers{
+			config: config,
+		}
+		up := L.NewUserData()
+		up.Value = members
+		mt.RawSetString("__index", L.NewClosure(structIndex, up))
+		mt.RawSetString("__newindex", L.NewClosure(structNewIndex, up))
 		mt.RawSetString("__eq", L.NewFunction(eq))
 	default:
 		mt = L.CreateTable(0, 8)
@@ -166,8 +215,12 @@ func getMetatable(L *lua.LState, vtype reflect.Type) *lua.LTable {
 
 	mt.RawSetString("__tostring", L.NewFunction(tostring))
 	mt.RawSetString("__metatable", L.CreateTable(0, 0))
-	mt.RawSetString("__pow", L.NewFunction(ptrPow))
-	mt.RawSetString("__unm", L.NewFunction(ptrUnm))
+	if vtype.Kind() != reflect.Interface {
+		// Values exposed as an interface must not be dereferenced, since
+		// that would expose the concrete type.
+		mt.RawSetString("__pow", L.NewFunction(ptrPow))
+		mt.RawSetString("__unm", L.NewFunction(ptrUnm))
+	}
 
 	addMethods(L, config, reflect.PtrTo(vtype), ptrMethods, true)
 	mt.RawSetString("ptr_methods", ptrMethods)
@@ -175,15 +228,64 @@ func getMetatable(L *lua.LState, vtype reflect.Type) *lua.LTable {
 	addMethods(L, config, vtype, methods, false)
 	mt.RawSetString("methods", methods)
 
-	config.regular[vtype] = mt
+	if fields != nil {
+		checkMemberCollisions(config, vtype, fields, methods, ptrMethods)
+	}
+	if config.settings().CaseInsensitiveNames {
+		mt.RawSetString("case_insensitive", lua.LTrue)
+	}
+	if members != nil {
+		members.build(vtype, fields, methods, ptrMethods)
+	}
 	return mt
 }
 
+// userDataMetatable returns the metatable of a luar userdata, which is
+// rebuilt first if its type was invalidated.
+func userDataMetatable(L *lua.LState, ud *lua.LUserData) *Metatable {
+	mt := ud.Metatable.(*lua.LTable)
+	if config := GetConfig(L); config.isStale(mt) {
+		config.rebuildMetatable(L, config.stale[mt], mt)
+	}
+	return &Metatable{LTable: mt}
+}
+
 func getMetatableFromValue(L *lua.LState, value reflect.Value) *lua.LTable {
 	vtype := value.Type()
 	return getMetatable(L, vtype)
 }
 
+func getOpaqueMetatable(L *lua.LState) *lua.LTable {
+	config := GetConfig(L)
+
+	if config.opaque != nil {
+		return config.opaque
+	}
+
+	mt := L.CreateTable(0, 2)
+	mt.RawSetString("__tostring", L.NewFunction(opaqueTostring))
+	mt.RawSetString("__metatable", L.CreateTable(0, 0))
+
+	config.opaque = mt
+	return mt
+}
+
+func getErrorMetatable(L *lua.LState) *lua.LTable {
+	config := GetConfig(L)
+
+	if config.errors != nil {
+		return config.errors
+	}
+
+	mt := L.CreateTable(0, 3)
+	mt.RawSetString("__tostring", L.NewFunction(errorTostring))
+	mt.RawSetString("__concat", L.NewFunction(errorConcat))
+	mt.RawSetString("__metatable", L.CreateTable(0, 0))
+
+	config.errors = mt
+	return mt
+}
+
 func getTypeMetatable(L *lua.LState, t reflect.Type) *lua.LTable {
 	config := GetConfig(L)
 
@@ -38,6 +38,11 @@ func chanEq(L *lua.LState) int {
 	ref1, _, _, isPtr1 := check(L, 1, reflect.Chan)
 	ref2, _, _, isPtr2 := check(L, 2, reflect.Chan)
 
+	if equal, ok := customEqual(L, ref1, ref2); ok {
+		L.Push(lua.LBool(equal))
+		return 1
+	}
+
 	if isPtr1 && isPtr2 {
 		L.Push(lua.LBool(ref1.Pointer() == ref2.Pointer()))
 		return 1
@@ -55,6 +60,7 @@ func chanEq(L *lua.LState) int {
 
 func chanSend(L *lua.LState) int {
 	ref, _, _, _ := check(L, 1, reflect.Chan)
+	checkAccess(L, ref.Type(), "", AccessSend)
 	value := L.CheckAny(2)
 	convertedValue := lValueToReflect(L, value, ref.Type().Elem(), nil)
 	if !convertedValue.IsValid() {
@@ -65,7 +71,8 @@ func chanSend(L *lua.LState) int {
 }
 
 func chanReceive(L *lua.LState) int {
-	ref, _, _, _ := check(L, 1, reflect.Chan)
+	ref, opts, _, _ := check(L, 1, reflect.Chan)
+	checkAccess(L, ref.Type(), "", AccessReceive)
 
 	value, ok := ref.Recv()
 	if !ok {
@@ -73,7 +80,7 @@ func chanReceive(L *lua.LState) int {
 		L.Push(lua.LBool(false))
 		return 2
 	}
-	L.Push(New(L, value.Interface()))
+	L.Push(New(L, value.Interface(), opts))
 	L.Push(lua.LBool(true))
 	return 2
 }
@@ -84,6 +91,7 @@ func chanClose(L *lua.LState) int {
 	if opts.Immutable {
 		L.RaiseError("cannot close immutable channel")
 	}
+	checkAccess(L, ref.Type(), "", AccessClose)
 
 	ref.Close()
 	return 0
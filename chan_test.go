@@ -66,3 +66,16 @@ func Test_chan_immutable_close(t *testing.T) {
 
 	testError(t, L, `ch:close()`, "cannot close immutable channel")
 }
+
+func Test_chan_immutable_receive(t *testing.T) {
+	// Values received from an immutable channel should be immutable
+	L := lua.NewState()
+	defer L.Close()
+
+	ch := make(chan *StructTestPerson, 1)
+	ch <- &StructTestPerson{Name: "Tim"}
+
+	L.SetGlobal("ch", New(L, ch, ReflectOptions{Immutable: true}))
+
+	testError(t, L, `p = ch:receive(); p.Name = "Bob"`, "invalid operation on immutable struct")
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	luarPath = "github.com/oscarhealth/gopher-luar"
+	luaPath  = "github.com/yuin/gopher-lua"
+)
+
+// generator generates the static accessors of the types of a package.
+type generator struct {
+	// The name of the generated registration function.
+	FuncName string
+	// The names of the types to generate accessors for.
+	Types []string
+
+	pkg     *types.Package
+	imports map[string]string
+	buf     bytes.Buffer
+}
+
+// generate loads the package matching pattern and returns the formatted
+// source of the generated file, and the directory of the package.
+func (g *generator) generate(pattern string) ([]byte, string, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(pkgs) != 1 {
+		return nil, "", fmt.Errorf("%d packages match %s, expecting 1", len(pkgs), pattern)
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, "", pkg.Errors[0]
+	}
+	if len(pkg.GoFiles) == 0 {
+		return nil, "", fmt.Errorf("package %s has no Go files", pkg.PkgPath)
+	}
+
+	src, err := g.generatePackage(pkg.Types)
+	if err != nil {
+		return nil, "", err
+	}
+	return src, filepath.Dir(pkg.GoFiles[0]), nil
+}
+
+// generatePackage returns the formatted source of the generated file for the
+// types of pkg.
+func (g *generator) generatePackage(pkg *types.Package) ([]byte, error) {
+	g.pkg = pkg
+	g.imports = map[string]string{
+		luarPath: "luar",
+		luaPath:  "lua",
+	}
+
+	g.buf.Reset()
+	for _, name := range g.Types {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Path())
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			return nil, fmt.Errorf("%s is not a named non-generic type", name)
+		}
+		st, ok := named.Underlying().(*types.Struct)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a struct type", name)
+		}
+		g.generateType(named, st)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by luar-gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg.Name())
+	fmt.Fprintf(&out, "import (\n")
+	importPaths := make([]string, 0, len(g.imports))
+	for importPath := range g.imports {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+	for _, importPath := range importPaths {
+		if name := g.imports[importPath]; name != path.Base(importPath) {
+			fmt.Fprintf(&out, "\t%s %s\n", name, strconv.Quote(importPath))
+		} else {
+			fmt.Fprintf(&out, "\t%s\n", strconv.Quote(importPath))
+		}
+	}
+	fmt.Fprintf(&out, ")\n\n")
+	fmt.Fprintf(&out, "// %s registers the static luar accessors of %s with c.\n", g.FuncName, joinNames(g.Types))
+	fmt.Fprintf(&out, "func %s(c *luar.Config) {\n", g.FuncName)
+	out.Write(g.buf.Bytes())
+	fmt.Fprintf(&out, "}\n")
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %s", err)
+	}
+	return src, nil
+}
+
+func joinNames(names []string) string {
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	}
+	s := ""
+	for i, name := range names {
+		switch {
+		case i == len(names)-1:
+			s += " and "
+		case i > 0:
+			s += ", "
+		}
+		s += name
+	}
+	return s
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// generateType generates the registration of the accessors of named.
+func (g *generator) generateType(named *types.Named, st *types.Struct) {
+	name := named.Obj().Name()
+	g.printf("c.RegisterStatic((*%s)(nil), luar.StaticType{\n", name)
+
+	g.printf("Fields: map[string]luar.StaticField{\n")
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Exported() || field.Embedded() || reflect.StructTag(st.Tag(i)).Get("luar") == "-" {
+			continue
+		}
+		kind, ok := g.basicKind(field.Type())
+		if !ok {
+			continue
+		}
+		g.printf("%s: {\n", strconv.Quote(field.Name()))
+		g.printf("Get: func(value interface{}) (lua.LValue, bool) {\n")
+		g.printf("v, ok := value.(*%s)\n", name)
+		g.printf("if !ok {\nreturn nil, false\n}\n")
+		g.printf("return %s, true\n", toLua(kind, field.Type(), "v."+field.Name()))
+		g.printf("},\n")
+		g.printf("Set: func(value interface{}, lv lua.LValue) bool {\n")
+		g.printf("v, ok := value.(*%s)\n", name)
+		g.printf("arg, isArg := lv.(%s)\n", luaType(kind))
+		g.printf("if !ok || !isArg {\nreturn false\n}\n")
+		if check := inRange(kind, "arg"); check != "" {
+			g.printf("if !(%s) {\nreturn false\n}\n", check)
+		}
+		g.printf("v.%s = %s\n", field.Name(), g.fromLua(kind, field.Type(), "arg"))
+		g.printf("return true\n")
+		g.printf("},\n")
+		g.printf("},\n")
+	}
+	g.printf("},\n")
+
+	g.printf("Methods: map[string]luar.FastPath{\n")
+	methods := types.NewMethodSet(types.NewPointer(named))
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.At(i).Obj().(*types.Func)
+		if !method.Exported() {
+			continue
+		}
+		g.generateMethod(name, method)
+	}
+	g.printf("},\n")
+
+	g.printf("})\n")
+}
+
+// generateMethod generates the caller of method if its arguments and result
+// are supported.
+func (g *generator) generateMethod(typeName string, method *types.Func) {
+	sig := method.Type().(*types.Signature)
+	if sig.Variadic() || sig.Results().Len() > 1 {
+		return
+	}
+	params := make([]types.BasicKind, sig.Params().Len())
+	for i := range params {
+		kind, ok := g.basicKind(sig.Params().At(i).Type())
+		if !ok {
+			return
+		}
+		params[i] = kind
+	}
+	var result types.BasicKind
+	if sig.Results().Len() == 1 {
+		kind, ok := g.basicKind(sig.Results().At(0).Type())
+		if !ok {
+			return
+		}
+		result = kind
+	}
+
+	g.printf("%s: func(L *lua.LState, _ interface{}) (int, bool) {\n", strconv.Quote(method.Name()))
+	g.printf("value, _, _ := luar.Unwrap(L.Get(1))\n")
+	g.printf("v, ok := value.(*%s)\n", typeName)
+	cond := "!ok"
+	args := make([]string, len(params))
+	for i, kind := range params {
+		g.printf("arg%d, ok%d := L.Get(%d).(%s)\n", i, i, i+2, luaType(kind))
+		cond += fmt.Sprintf(" || !ok%d", i)
+		if check := inRange(kind, fmt.Sprintf("arg%d", i)); check != "" {
+			cond += fmt.Sprintf(" || !(%s)", check)
+		}
+		args[i] = g.fromLua(kind, sig.Params().At(i).Type(), fmt.Sprintf("arg%d", i))
+	}
+	g.printf("if %s || L.GetTop() != %d {\nreturn 0, false\n}\n", cond, len(params)+1)
+
+	call := "v." + method.Name() + "("
+	for i, arg := range args {
+		if i > 0 {
+			call += ", "
+		}
+		call += arg
+	}
+	call += ")"
+	if sig.Results().Len() == 0 {
+		g.printf("%s\n", call)
+		g.printf("return 0, true\n")
+	} else {
+		g.printf("L.Push(%s)\n", toLua(result, sig.Results().At(0).Type(), call))
+		g.printf("return 1, true\n")
+	}
+	g.printf("},\n")
+}
+
+// basicKind returns the kind of the underlying type of t if values of t are
+// converted to and from Lua booleans, numbers or strings.
+func (g *generator) basicKind(t types.Type) (types.BasicKind, bool) {
+	if named, ok := t.(*types.Named); ok {
+		// Types of gopher-lua, such as lua.LString, are passed unchanged.
+		if pkg := named.Obj().Pkg(); pkg != nil && pkg.Path() == luaPath {
+			return 0, false
+		}
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return 0, false
+	}
+	switch basic.Kind() {
+	case types.Bool, types.String,
+		types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+		types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+		types.Float32, types.Float64:
+		return basic.Kind(), true
+	}
+	return 0, false
+}
+
+// luaType returns the Lua value type that is converted to kind without loss.
+func luaType(kind types.BasicKind) string {
+	switch kind {
+	case types.Bool:
+		return "lua.LBool"
+	case types.String:
+		return "lua.LString"
+	}
+	return "lua.LNumber"
+}
+
+// toLua returns the conversion of expr, of type t and the given kind, to a
+// Lua value, as done by luar.New.
+func toLua(kind types.BasicKind, t types.Type, expr string) string {
+	switch {
+	case kind == types.Bool:
+		return "lua.LBool(" + expr + ")"
+	case kind == types.String:
+		return "lua.LString(" + expr + ")"
+	case types.Identical(t, types.Typ[types.Float64]):
+		return "lua.LNumber(" + expr + ")"
+	}
+	return "lua.LNumber(float64(" + expr + "))"
+}
+
+// fromLua returns the conversion of the Lua value expr to t, as done by
+// reflect.Value.Convert for the arguments of reflected functions. Numbers
+// converted to integers must be in range (see inRange).
+func (g *generator) fromLua(kind types.BasicKind, t types.Type, expr string) string {
+	typeName := types.TypeString(t, g.qualifier)
+	switch kind {
+	case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+		expr = "int64(" + expr + ")"
+		if types.Identical(t, types.Typ[types.Int64]) {
+			return expr
+		}
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		expr = "uint64(" + expr + ")"
+		if types.Identical(t, types.Typ[types.Uint64]) {
+			return expr
+		}
+	}
+	return typeName + "(" + expr + ")"
+}
+
+// inRange returns the condition under which the Lua number expr can be
+// converted to an integer of the given kind without overflow, or "" for other
+// kinds. The conversion of other numbers is implementation-defined, so they
+// are left to the reflective path. The range of int and uint is that of int32
+// and uint32, which is portable.
+func inRange(kind types.BasicKind, expr string) string {
+	var min, max string
+	switch kind {
+	case types.Int8:
+		min, max = "-1<<7", "1<<7"
+	case types.Int16:
+		min, max = "-1<<15", "1<<15"
+	case types.Int, types.Int32:
+		min, max = "-1<<31", "1<<31"
+	case types.Int64:
+		min, max = "-1<<63", "1<<63"
+	case types.Uint8:
+		min, max = "0", "1<<8"
+	case types.Uint16:
+		min, max = "0", "1<<16"
+	case types.Uint, types.Uint32:
+		min, max = "0", "1<<32"
+	case types.Uint64:
+		min, max = "0", "1<<64"
+	default:
+		return ""
+	}
+	// NaN fails both comparisons.
+	return expr + " >= " + min + " && " + expr + " < " + max
+}
+
+// qualifier returns the name under which pkg is imported by the generated
+// file.
+func (g *generator) qualifier(pkg *types.Package) string {
+	if pkg == g.pkg {
+		return ""
+	}
+	if name, ok := g.imports[pkg.Path()]; ok {
+		return name
+	}
+	name := pkg.Name()
+	for taken := true; taken; {
+		taken = false
+		for _, other := range g.imports {
+			if other == name {
+				taken = true
+				name += "_"
+				break
+			}
+		}
+	}
+	g.imports[pkg.Path()] = name
+	return name
+}
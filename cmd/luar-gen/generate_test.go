@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	luar "github.com/oscarhealth/gopher-luar"
+	"github.com/oscarhealth/gopher-luar/cmd/luar-gen/testdata/claims"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func Test_generate(t *testing.T) {
+	g := &generator{
+		FuncName: "RegisterLuar",
+		Types:    []string{"Claim", "Member"},
+	}
+	src, dir, err := g.generate("./testdata/claims")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(filepath.ToSlash(dir), "testdata/claims") {
+		t.Fatalf("unexpected package directory %s", dir)
+	}
+
+	// testdata/claims/luar_gen.go is regenerated with:
+	//  go run . -type Claim,Member ./testdata/claims
+	golden, err := os.ReadFile(filepath.Join(dir, "luar_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, golden) {
+		t.Fatalf("generated code differs from testdata/claims/luar_gen.go:\n%s", src)
+	}
+
+	for _, snippet := range []string{
+		`"ID": {`,
+		`v.Delay = time.Duration(int64(arg))`,
+		`v.SetStatus(Status(arg0))`,
+		`"Greet": func(L *lua.LState, _ interface{}) (int, bool) {`,
+	} {
+		if !bytes.Contains(src, []byte(snippet)) {
+			t.Errorf("expecting generated code to contain %s", snippet)
+		}
+	}
+	// Embedded, unexported, hidden and non-basic fields, and methods with
+	// unsupported signatures, are left to reflection.
+	for _, snippet := range []string{`"Base"`, `"Created"`, `"Lines"`, `"Member": {`, `"Internal"`, `"secret"`, `"Validate"`, `"Tags"`, `"secretValue"`} {
+		if bytes.Contains(src, []byte(snippet)) {
+			t.Errorf("expecting generated code not to contain %s", snippet)
+		}
+	}
+}
+
+func Test_generate_errors(t *testing.T) {
+	tests := []struct {
+		Types []string
+		Err   string
+	}{
+		{[]string{"Claim", "Payment"}, "type Payment not found"},
+		{[]string{"Status"}, "Status is not a struct type"},
+	}
+	for _, test := range tests {
+		g := &generator{FuncName: "RegisterLuar", Types: test.Types}
+		_, _, err := g.generate("./testdata/claims")
+		if err == nil || !strings.Contains(err.Error(), test.Err) {
+			t.Errorf("expecting error %q for %v, got %v", test.Err, test.Types, err)
+		}
+	}
+}
+
+type note struct {
+	Text string
+}
+
+// runClaims runs script with a claim, a member and a reflected note, and
+// returns its results as strings.
+func runClaims(t *testing.T, L *lua.LState, script string) []string {
+	claim := &claims.Claim{ID: 12, Status: "open", Amount: 10, Code: 3, Lines: []string{"a"}}
+	claim.Member = &claims.Member{Name: "ana", Age: 40}
+	L.SetGlobal("claim", luar.New(L, claim))
+	L.SetGlobal("note", luar.New(L, &note{Text: "checked"}))
+	L.SetGlobal("attach", luar.New(L, func(c *claims.Claim, n *note) string {
+		return string(c.Status) + ": " + n.Text
+	}))
+
+	top := L.GetTop()
+	if err := L.DoString(script); err != nil {
+		t.Fatal(err)
+	}
+	var results []string
+	for i := top + 1; i <= L.GetTop(); i++ {
+		results = append(results, L.Get(i).String())
+	}
+	L.SetTop(top)
+	return results
+}
+
+func Test_generated_bindings(t *testing.T) {
+	static := lua.NewState()
+	defer static.Close()
+	claims.RegisterLuar(luar.GetConfig(static))
+	reflected := lua.NewState()
+	defer reflected.Close()
+
+	scripts := []string{
+		`return claim.ID, claim.Status, claim.total, claim.Code, claim.Approved, claim.Delay`,
+		`claim.ID = 13.7; claim.Code = 4; claim.Status = "closed"; claim.Delay = 5
+		return claim.ID, claim.Code, claim.Status, claim.Delay`,
+		// Values that the static accessors do not handle are converted by
+		// reflection.
+		`claim.Status = 12; claim.Approved = true; return claim.Status, claim.Approved`,
+		`claim:Approve(); return claim.Approved, claim.Status, claim:Describe("status: ")`,
+		`claim:SetStatus("paid"); return claim:Add(2.5, 2), claim.total, claim:Add(1, 2.9), claim:Validate()`,
+		`return claim.Member.Name, claim.Member:Greet(), claim.Member.Age`,
+		`claim.Member.Active = true; claim.Member.Name = "bo"; return claim.Member.Active, claim.Member:Greet()`,
+		`return claim.Created, claim:Tags("a", "b"), #claim.Lines`,
+		`return attach(claim, note), note.Text`,
+		`return pcall(function() return claim.Internal end)`,
+		// Numbers that overflow the integer types are converted by
+		// reflection.
+		`claim.Code = 255; claim.Member.Age = 7; return claim.Code, claim.Member.Age`,
+		`claim.Code = -1; claim.Member.Age = -1; return claim.Code, claim.Member.Age`,
+		`claim.Code = 300; claim.ID = -2^63; return claim.Code, claim.ID`,
+		`claim.Code = 0/0; return claim.Code`,
+		`return claim:Add(1, -2^31), claim:Add(1, 2^31)`,
+		`return pcall(function() claim.Code = "x" end)`,
+		`return pcall(function() claim:Add("x", 1) end)`,
+	}
+	for _, script := range scripts {
+		expected := runClaims(t, reflected, script)
+		actual := runClaims(t, static, script)
+		if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("%s\nstatic:    %q\nreflected: %q", script, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Command luar-gen generates static luar accessors for Go struct types.
+//
+// The generated code reads and writes fields, and calls methods, without
+// reflection. It registers the accessors with a luar.Config (see
+// luar.StaticType); the names of the members, tags, naming functions,
+// bindings and the access policy are handled by luar as for other types, so
+// values of generated and reflected types can be mixed in one LState.
+//
+// Accessors are generated for exported fields of boolean, numeric and string
+// types, and for exported methods whose arguments and result are of such
+// types. Other fields and methods use reflection.
+//
+// Usage:
+//  luar-gen -type Claim,Member [-output file] [-func name] [package]
+//
+// The package defaults to the package in the current directory, and the
+// output file defaults to luar_gen.go in the directory of the package. The
+// generated file declares a function (RegisterLuar by default) that has to
+// be called with the configuration of every LState:
+//  claims.RegisterLuar(luar.GetConfig(L))
+//
+// It can be used with go generate:
+//  //go:generate luar-gen -type Claim,Member
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of type names; required")
+	output := flag.String("output", "", "output file name; default <package dir>/luar_gen.go")
+	funcName := flag.String("func", "RegisterLuar", "name of the generated registration function")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: luar-gen -type T[,T...] [-output file] [-func name] [package]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	pattern := "."
+	if flag.NArg() == 1 {
+		pattern = flag.Arg(0)
+	}
+
+	g := &generator{
+		FuncName: *funcName,
+		Types:    strings.Split(*typeNames, ","),
+	}
+	src, dir, err := g.generate(pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "luar-gen: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		*output = filepath.Join(dir, "luar_gen.go")
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "luar-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
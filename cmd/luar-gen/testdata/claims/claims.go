@@ -0,0 +1,75 @@
+// Package claims is the input of the luar-gen tests.
+package claims
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+//go:generate luar-gen -type Claim,Member
+
+type Status string
+
+type Base struct {
+	Created int64
+}
+
+type Claim struct {
+	Base
+
+	ID       int64
+	Status   Status
+	Amount   float64 `luar:"total"`
+	Approved bool
+	Code     uint8
+	Delay    time.Duration
+	Lines    []string
+	Member   *Member
+	Internal string `luar:"-"`
+
+	secret string
+}
+
+func (c *Claim) Approve() {
+	c.Approved = true
+	c.Status = "approved"
+}
+
+func (c *Claim) SetStatus(status Status) {
+	c.Status = status
+}
+
+func (c Claim) Describe(prefix string) string {
+	return prefix + strings.ToUpper(string(c.Status))
+}
+
+func (c *Claim) Add(amount float64, times int) float64 {
+	c.Amount += amount * float64(times)
+	return c.Amount
+}
+
+func (c *Claim) Validate() error {
+	if c.Amount < 0 {
+		return errors.New("negative amount")
+	}
+	return nil
+}
+
+func (c *Claim) Tags(tags ...string) int {
+	return len(tags)
+}
+
+func (c *Claim) secretValue() string {
+	return c.secret
+}
+
+type Member struct {
+	Name   string
+	Age    uint
+	Active bool
+}
+
+func (m *Member) Greet() string {
+	return "hello " + m.Name
+}
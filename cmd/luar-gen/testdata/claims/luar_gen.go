@@ -0,0 +1,248 @@
+// Code generated by luar-gen; DO NOT EDIT.
+
+package claims
+
+import (
+	luar "github.com/oscarhealth/gopher-luar"
+	lua "github.com/yuin/gopher-lua"
+	"time"
+)
+
+// RegisterLuar registers the static luar accessors of Claim and Member with c.
+func RegisterLuar(c *luar.Config) {
+	c.RegisterStatic((*Claim)(nil), luar.StaticType{
+		Fields: map[string]luar.StaticField{
+			"ID": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Claim)
+					if !ok {
+						return nil, false
+					}
+					return lua.LNumber(float64(v.ID)), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Claim)
+					arg, isArg := lv.(lua.LNumber)
+					if !ok || !isArg {
+						return false
+					}
+					if !(arg >= -1<<63 && arg < 1<<63) {
+						return false
+					}
+					v.ID = int64(arg)
+					return true
+				},
+			},
+			"Status": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Claim)
+					if !ok {
+						return nil, false
+					}
+					return lua.LString(v.Status), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Claim)
+					arg, isArg := lv.(lua.LString)
+					if !ok || !isArg {
+						return false
+					}
+					v.Status = Status(arg)
+					return true
+				},
+			},
+			"Amount": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Claim)
+					if !ok {
+						return nil, false
+					}
+					return lua.LNumber(v.Amount), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Claim)
+					arg, isArg := lv.(lua.LNumber)
+					if !ok || !isArg {
+						return false
+					}
+					v.Amount = float64(arg)
+					return true
+				},
+			},
+			"Approved": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Claim)
+					if !ok {
+						return nil, false
+					}
+					return lua.LBool(v.Approved), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Claim)
+					arg, isArg := lv.(lua.LBool)
+					if !ok || !isArg {
+						return false
+					}
+					v.Approved = bool(arg)
+					return true
+				},
+			},
+			"Code": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Claim)
+					if !ok {
+						return nil, false
+					}
+					return lua.LNumber(float64(v.Code)), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Claim)
+					arg, isArg := lv.(lua.LNumber)
+					if !ok || !isArg {
+						return false
+					}
+					if !(arg >= 0 && arg < 1<<8) {
+						return false
+					}
+					v.Code = uint8(uint64(arg))
+					return true
+				},
+			},
+			"Delay": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Claim)
+					if !ok {
+						return nil, false
+					}
+					return lua.LNumber(float64(v.Delay)), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Claim)
+					arg, isArg := lv.(lua.LNumber)
+					if !ok || !isArg {
+						return false
+					}
+					if !(arg >= -1<<63 && arg < 1<<63) {
+						return false
+					}
+					v.Delay = time.Duration(int64(arg))
+					return true
+				},
+			},
+		},
+		Methods: map[string]luar.FastPath{
+			"Add": func(L *lua.LState, _ interface{}) (int, bool) {
+				value, _, _ := luar.Unwrap(L.Get(1))
+				v, ok := value.(*Claim)
+				arg0, ok0 := L.Get(2).(lua.LNumber)
+				arg1, ok1 := L.Get(3).(lua.LNumber)
+				if !ok || !ok0 || !ok1 || !(arg1 >= -1<<31 && arg1 < 1<<31) || L.GetTop() != 3 {
+					return 0, false
+				}
+				L.Push(lua.LNumber(v.Add(float64(arg0), int(int64(arg1)))))
+				return 1, true
+			},
+			"Approve": func(L *lua.LState, _ interface{}) (int, bool) {
+				value, _, _ := luar.Unwrap(L.Get(1))
+				v, ok := value.(*Claim)
+				if !ok || L.GetTop() != 1 {
+					return 0, false
+				}
+				v.Approve()
+				return 0, true
+			},
+			"Describe": func(L *lua.LState, _ interface{}) (int, bool) {
+				value, _, _ := luar.Unwrap(L.Get(1))
+				v, ok := value.(*Claim)
+				arg0, ok0 := L.Get(2).(lua.LString)
+				if !ok || !ok0 || L.GetTop() != 2 {
+					return 0, false
+				}
+				L.Push(lua.LString(v.Describe(string(arg0))))
+				return 1, true
+			},
+			"SetStatus": func(L *lua.LState, _ interface{}) (int, bool) {
+				value, _, _ := luar.Unwrap(L.Get(1))
+				v, ok := value.(*Claim)
+				arg0, ok0 := L.Get(2).(lua.LString)
+				if !ok || !ok0 || L.GetTop() != 2 {
+					return 0, false
+				}
+				v.SetStatus(Status(arg0))
+				return 0, true
+			},
+		},
+	})
+	c.RegisterStatic((*Member)(nil), luar.StaticType{
+		Fields: map[string]luar.StaticField{
+			"Name": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Member)
+					if !ok {
+						return nil, false
+					}
+					return lua.LString(v.Name), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Member)
+					arg, isArg := lv.(lua.LString)
+					if !ok || !isArg {
+						return false
+					}
+					v.Name = string(arg)
+					return true
+				},
+			},
+			"Age": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Member)
+					if !ok {
+						return nil, false
+					}
+					return lua.LNumber(float64(v.Age)), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Member)
+					arg, isArg := lv.(lua.LNumber)
+					if !ok || !isArg {
+						return false
+					}
+					if !(arg >= 0 && arg < 1<<32) {
+						return false
+					}
+					v.Age = uint(uint64(arg))
+					return true
+				},
+			},
+			"Active": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*Member)
+					if !ok {
+						return nil, false
+					}
+					return lua.LBool(v.Active), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*Member)
+					arg, isArg := lv.(lua.LBool)
+					if !ok || !isArg {
+						return false
+					}
+					v.Active = bool(arg)
+					return true
+				},
+			},
+		},
+		Methods: map[string]luar.FastPath{
+			"Greet": func(L *lua.LState, _ interface{}) (int, bool) {
+				value, _, _ := luar.Unwrap(L.Get(1))
+				v, ok := value.(*Member)
+				if !ok || L.GetTop() != 1 {
+					return 0, false
+				}
+				L.Push(lua.LString(v.Greet()))
+				return 1, true
+			},
+		},
+	})
+}
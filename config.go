@@ -17,6 +17,9 @@ type Config struct {
 	//  - if the tag is "-", no name is returned (i.e. the field is not
 	//    accessible)
 	//  - for any other tag value, that value is returned
+	//
+	// SnakeCaseFieldNames, CamelCaseFieldNames and JSONFieldNames provide
+	// other common naming strategies.
 	FieldNames func(s reflect.Type, f reflect.StructField) []string
 
 	// The name generating function that defines under which names Go
@@ -24,15 +27,145 @@ type Config struct {
 	//
 	// If nil, the default behaviour is used:
 	//   - the method name and its name with a lowercase first letter
+	//
+	// SnakeCaseMethodNames and CamelCaseMethodNames provide other common
+	// naming strategies.
 	MethodNames func(t reflect.Type, m reflect.Method) []string
 
+	// Controls whether fields and methods can be accessed regardless of the
+	// case of their names, e.g. p.NAME, p.Name and p.name. The lowercase
+	// variants of the names returned by FieldNames and MethodNames are added
+	// to the metatables, and are used when a name is not found.
+	CaseInsensitiveNames bool
+
+	// The function that is called when a name returned by FieldNames or
+	// MethodNames (or its lowercase variant, see CaseInsensitiveNames) is used
+	// by more than one field or method of a type, or by both a field and a
+	// method. The first field or method keeps the name; methods take
+	// precedence over fields. Fields of embedded structs that are shadowed
+	// like in Go are not reported.
+	//
+	// If nil, collisions are ignored.
+	NameCollisionHandler func(collision NameCollision)
+
+	// The function that classifies Go methods as pure or mutating. Only pure
+	// methods can be called on immutable values.
+	//
+	// If nil, or if MethodDefault is returned, the default behaviour is used:
+	//   - if the type implements PureMethods, only the listed methods are
+	//     pure
+	//   - otherwise, methods with a value receiver are pure and methods with
+	//     a pointer receiver are mutating
+	MethodPolicy func(t reflect.Type, m reflect.Method) MethodKind
+
+	// The function that decides whether Lua code may access a Go value. It is
+	// called before a field, method or element is accessed. If a non-nil
+	// error is returned, the access is denied and the message of the error is
+	// raised as a Lua error. The error itself can be retrieved using
+	// WrapError.
+	//
+	// If nil, all accesses are allowed.
+	AccessPolicy func(ctx AccessContext) error
+
+	// Controls whether allowed accesses are remembered per type, member and
+	// operation, so that AccessPolicy is only called once for each of them.
+	// Denied accesses are never cached. This should only be enabled if the
+	// decisions of AccessPolicy do not change over time.
+	CacheAccessPolicy bool
+
+	// The function that decides which Go types are reflected with their
+	// fields, methods and operators. Arrays, channels, maps, pointers, slices
+	// and structs whose type is not exposed are converted to opaque userdata
+	// (see ReflectOptions.Opaque).
+	//
+	// If nil, all types are exposed.
+	ExposedTypes func(t reflect.Type) bool
+
+	// Limits for allocations that luar performs on behalf of Lua code. When a
+	// limit is exceeded, a Lua error is raised instead of allocating. Zero
+	// means no limit.
+	//
+	// MaxSliceLength limits the length of slices and arrays created by type
+	// constructors, appends, and conversions from tables. MaxMapEntries limits
+	// the number of entries of maps converted from tables and of maps that
+	// Lua code adds entries to. MaxChanBuffer
+	// limits the buffer size of channels created by type constructors.
+	// MaxAllocBytes limits the total approximate number of bytes allocated by
+	// luar (see AllocatedBytes).
+	MaxSliceLength int
+	MaxMapEntries  int
+	MaxChanBuffer  int
+	MaxAllocBytes  int64
+
+	// The function that is called when a Go function called from Lua panics.
+	// The panic is raised as a Lua error after the handler returns.
+	PanicHandler func(L *lua.LState, err *PanicError)
+
+	// Controls whether the Go stack is captured in PanicError.Stack.
+	PanicStack bool
+
+	// Controls whether errors returned from Go functions are raised as Lua
+	// errors (see Raise). If set, a function whose last result is of type
+	// error raises that error if it is non-nil, and otherwise returns its
+	// other results only.
+	RaiseReturnedErrors bool
+
+	// The function that converts luar values that implement neither
+	// fmt.Stringer nor error to strings, e.g. for Lua's tostring and print
+	// functions.
+	//
+	// If nil, the default behaviour is used: the name of the Go type is
+	// followed by a summary of the first few fields or elements, e.g.
+	// `Claim{ID=12, Amount=40.5, Member=Member{...}, ...}`. Fields that
+	// cannot be read because of the access policy are omitted.
+	Tostring func(L *lua.LState, value interface{}) string
+
+	// The function that selects how Lua's == operator compares luar values of
+	// the given type. It is called with the type of the left operand.
+	//
+	// If nil, or if EqualityDefault is returned, the default behaviour is
+	// used:
+	//   - if the value has a method "Equal(other T) bool" and the other value
+	//     can be converted to T, the method is called
+	//   - otherwise, arrays, interfaces, structs and pointers to them are
+	//     compared using reflect.DeepEqual, pointers to other types are
+	//     compared by address, and channels, maps and slices are compared as
+	//     before: pointers to them by address, channels by value, and maps
+	//     and slices not at all
+	// The options of the compared values (e.g. Immutable) are ignored.
+	Equality func(t reflect.Type) EqualityMode
+
+	// Controls whether repeated reflections of the same Go pointer, map or
+	// channel with the same type and options return the same userdata. This
+	// makes rawequal work for such values and allows them to be used as table
+	// keys. Userdata are cached using weak references, so the cache does not
+	// keep them alive. Requires Go 1.24 or later; ignored otherwise.
+	IdentityCache bool
+
+	middlewares []func(next CallHandler) CallHandler
+	handler     CallHandler
+
 	regular, types map[reflect.Type]*lua.LTable
+	allowed        map[accessKey]struct{}
+	bindings       map[reflect.Type]*typeBinding
+	static         map[reflect.Type]*StaticType
+	bypassOptions  map[*lua.LState][]ReflectOptions
+	stale          map[*lua.LTable]reflect.Type
+	frozen         *Config
+	opaque, errors *lua.LTable
+	identity       identityCache
+	allocated      int64
+
+	// Set by benchmarks to call functions through reflection even if they
+	// have a fast path.
+	disableFastPaths bool
 }
 
 func newConfig() *Config {
 	return &Config{
 		regular: make(map[reflect.Type]*lua.LTable),
 		types:   make(map[reflect.Type]*lua.LTable),
+		allowed: make(map[accessKey]struct{}),
 	}
 }
 
@@ -50,6 +183,33 @@ func GetConfig(L *lua.LState) *Config {
 	return lConfig.Value.(*Config)
 }
 
+// ExposeTypes returns a function that can be used as Config.ExposedTypes. It
+// exposes the types of the given values and pointers to those types.
+//
+// Example:
+//  GetConfig(L).ExposedTypes = ExposeTypes(Claim{}, Member{})
+func ExposeTypes(values ...interface{}) func(t reflect.Type) bool {
+	set := make(map[reflect.Type]struct{}, len(values))
+	for _, value := range values {
+		set[reflect.TypeOf(value)] = struct{}{}
+	}
+	return func(t reflect.Type) bool {
+		if _, ok := set[t]; ok {
+			return true
+		}
+		if t.Kind() == reflect.Ptr {
+			_, ok := set[t.Elem()]
+			return ok
+		}
+		return false
+	}
+}
+
+func (c *Config) isExposed(t reflect.Type) bool {
+	exposed := c.settings().ExposedTypes
+	return exposed == nil || exposed(t)
+}
+
 func defaultFieldNames(s reflect.Type, f reflect.StructField) []string {
 	const tagName = "luar"
 
@@ -72,3 +232,68 @@ func defaultMethodNames(t reflect.Type, m reflect.Method) []string {
 		getUnexportedName(m.Name),
 	}
 }
+
+// MethodKind describes whether a method can modify the state of its receiver.
+type MethodKind int
+
+const (
+	// MethodDefault leaves the classification to the default behaviour.
+	MethodDefault MethodKind = iota
+	// MethodPure marks methods that can be called on immutable values.
+	MethodPure
+	// MethodMutating marks methods that cannot be called on immutable values.
+	MethodMutating
+)
+
+// PureMethods can be implemented by a type to list the names of its methods
+// that do not modify any state. LuarPureMethods is called on the zero value
+// of the type, so it must not depend on the receiver.
+type PureMethods interface {
+	LuarPureMethods() []string
+}
+
+var refTypePureMethods = reflect.TypeOf((*PureMethods)(nil)).Elem()
+
+func (c *Config) methodKind(t reflect.Type, m *methodDescriptor) MethodKind {
+	if policy := c.settings().MethodPolicy; policy != nil {
+		if kind := policy(t, m.Method); kind != MethodDefault {
+			return kind
+		}
+	}
+	return m.Kind
+}
+
+func defaultMethodKind(t reflect.Type, m reflect.Method) MethodKind {
+	valueType := t
+	if t.Kind() == reflect.Ptr {
+		valueType = t.Elem()
+	}
+
+	var names []string
+	switch {
+	case valueType.Kind() == reflect.Interface && valueType.Implements(refTypePureMethods):
+		// The zero value of an interface type is nil, so the pure methods
+		// cannot be listed; all other methods are considered mutating.
+	case valueType.Implements(refTypePureMethods):
+		names = reflect.Zero(valueType).Interface().(PureMethods).LuarPureMethods()
+	case t.Implements(refTypePureMethods):
+		names = reflect.New(valueType).Interface().(PureMethods).LuarPureMethods()
+	case t.Kind() != reflect.Ptr:
+		return MethodPure
+	default:
+		if _, ok := valueType.MethodByName(m.Name); ok {
+			return MethodPure
+		}
+		return MethodMutating
+	}
+
+	if m.Name == "LuarPureMethods" {
+		return MethodPure
+	}
+	for _, name := range names {
+		if name == m.Name {
+			return MethodPure
+		}
+	}
+	return MethodMutating
+}
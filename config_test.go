@@ -77,3 +77,75 @@ func Test_config_methodnames(t *testing.T) {
 	testError(t, L, `return v:len()`, `attempt to call a non-function object`)
 	testReturn(t, L, `return v:length()`, `2`)
 }
+
+type TestConfigMethodPolicy struct {
+	Counts map[string]int
+}
+
+func (t TestConfigMethodPolicy) Count(name string) int {
+	return t.Counts[name]
+}
+
+func (t TestConfigMethodPolicy) Increment(name string) {
+	t.Counts[name]++
+}
+
+func Test_config_methodpolicy(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.MethodPolicy = func(s reflect.Type, m reflect.Method) MethodKind {
+		if m.Name == "Increment" {
+			return MethodMutating
+		}
+		return MethodDefault
+	}
+
+	v := TestConfigMethodPolicy{
+		Counts: map[string]int{"a": 1},
+	}
+
+	L.SetGlobal("v", New(L, v, ReflectOptions{Immutable: true}))
+	L.SetGlobal("w", New(L, v))
+
+	testReturn(t, L, `return v:Count("a")`, "1")
+	testError(t, L, `v:Increment("a")`, "cannot call mutating method Increment on immutable object")
+	testReturn(t, L, `w:Increment("a"); return v:Count("a")`, "2")
+}
+
+type TestConfigExposedHandle struct {
+	Secret string
+}
+
+type TestConfigExposedService struct {
+	handle *TestConfigExposedHandle
+}
+
+func (s *TestConfigExposedService) Handle() *TestConfigExposedHandle {
+	return s.handle
+}
+
+func (s *TestConfigExposedService) Check(h *TestConfigExposedHandle) bool {
+	return h == s.handle
+}
+
+func Test_config_exposedtypes(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.ExposedTypes = ExposeTypes(TestConfigExposedService{})
+
+	s := &TestConfigExposedService{
+		handle: &TestConfigExposedHandle{Secret: "password"},
+	}
+
+	L.SetGlobal("s", New(L, s))
+
+	testReturn(t, L, `h = s:Handle()`)
+	testError(t, L, `return h.Secret`, "attempt to index a non-table object")
+	testError(t, L, `h.Secret = "x"`, "attempt to index a non-table object")
+	testError(t, L, `return -h`, "__unm undefined")
+	testReturn(t, L, `return s:Check(h)`, "true")
+}
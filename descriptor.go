@@ -0,0 +1,242 @@
+package luar
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeDescriptor holds the reflection results about a Go type that do not
+// depend on the configuration of an LState. Descriptors are computed once per
+// process and shared by all LStates; the metatables of each LState are built
+// from them.
+type typeDescriptor struct {
+	// The accessible fields of a struct type, including the fields of
+	// embedded structs, in the order in which they are added to metatables.
+	Fields []fieldDescriptor
+	// The exported methods of the type.
+	Methods []methodDescriptor
+}
+
+type fieldDescriptor struct {
+	Field reflect.StructField
+	// The struct type that declares the field.
+	Owner reflect.Type
+	// The index of the field in the described struct type.
+	Index []int
+	// The names returned by the default Config.FieldNames.
+	Names []string
+	// Set for embedded structs and pointers to structs, whose fields are
+	// promoted.
+	Embedded bool
+}
+
+type methodDescriptor struct {
+	// The method, with the receiver as the first argument of its Type and
+	// Func, also for interface types.
+	Method reflect.Method
+	// The names returned by the default Config.MethodNames.
+	Names []string
+	// The classification of the method by the default Config.MethodPolicy.
+	Kind MethodKind
+}
+
+// Depth returns the number of embedded structs that the field is promoted
+// through.
+func (f *fieldDescriptor) Depth() int {
+	return len(f.Index) - 1
+}
+
+// typeDescriptors maps reflect.Type to *typeDescriptor.
+var typeDescriptors sync.Map
+
+// describeType returns the descriptor of t, computing it if necessary. It is
+// safe for concurrent use.
+func describeType(t reflect.Type) *typeDescriptor {
+	if desc, ok := typeDescriptors.Load(t); ok {
+		return desc.(*typeDescriptor)
+	}
+	desc, _ := typeDescriptors.LoadOrStore(t, newTypeDescriptor(t))
+	return desc.(*typeDescriptor)
+}
+
+func newTypeDescriptor(t reflect.Type) *typeDescriptor {
+	desc := &typeDescriptor{}
+	if t.Kind() == reflect.Struct {
+		desc.Fields = describeFields(t)
+	}
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		if method.PkgPath != "" {
+			continue
+		}
+		if t.Kind() == reflect.Interface {
+			method = interfaceMethod(t, method)
+		}
+		desc.Methods = append(desc.Methods, methodDescriptor{
+			Method: method,
+			Names:  defaultMethodNames(t, method),
+			Kind:   defaultMethodKind(t, method),
+		})
+	}
+	return desc
+}
+
+// describeFields returns the fields of the struct type t breadth first, with
+// the fields of each embedded struct following all fields of the same depth.
+func describeFields(t reflect.Type) []fieldDescriptor {
+	type element struct {
+		Type  reflect.Type
+		Index []int
+	}
+
+	var fields []fieldDescriptor
+	queue := []element{{Type: t}}
+	for len(queue) > 0 {
+		elem := queue[0]
+		queue = queue[1:]
+		for i := 0; i < elem.Type.NumField(); i++ {
+			field := elem.Type.Field(i)
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+			index := make([]int, len(elem.Index)+1)
+			copy(index, elem.Index)
+			index[len(elem.Index)] = i
+
+			desc := fieldDescriptor{
+				Field: field,
+				Owner: elem.Type,
+				Index: index,
+				Names: defaultFieldNames(elem.Type, field),
+			}
+			if field.Anonymous {
+				embedded := field.Type
+				if embedded.Kind() == reflect.Ptr {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					desc.Embedded = true
+					queue = append(queue, element{
+						Type:  embedded,
+						Index: index,
+					})
+				}
+			}
+			fields = append(fields, desc)
+		}
+	}
+	return fields
+}
+
+// field returns the descriptor of the field with the given index, or nil.
+func (desc *typeDescriptor) field(index []int) *fieldDescriptor {
+	for i := range desc.Fields {
+		if indexEqual(desc.Fields[i].Index, index) {
+			return &desc.Fields[i]
+		}
+	}
+	return nil
+}
+
+// method returns the descriptor of the method with the given name, or nil.
+func (desc *typeDescriptor) method(name string) *methodDescriptor {
+	for i := range desc.Methods {
+		if desc.Methods[i].Method.Name == name {
+			return &desc.Methods[i]
+		}
+	}
+	return nil
+}
+
+// conversionPlan holds what is needed to convert Lua values to a Go type, so
+// that it is not recomputed for every converted value or function call. Plans
+// are computed once per process and shared by all LStates.
+type conversionPlan struct {
+	// Set if the type implements lua.LValue; Lua values are then converted
+	// as is.
+	LValue bool
+	// The types that the arguments of a function type are converted to. For
+	// variadic functions, the last type is the element type of the variadic
+	// argument.
+	In []reflect.Type
+	// Set for bypass functions (see LState).
+	Bypass bool
+}
+
+// conversionPlans maps reflect.Type to *conversionPlan.
+var conversionPlans sync.Map
+
+// planConversion returns the conversion plan of t, computing it if necessary.
+// It is safe for concurrent use.
+func planConversion(t reflect.Type) *conversionPlan {
+	if plan, ok := conversionPlans.Load(t); ok {
+		return plan.(*conversionPlan)
+	}
+	plan, _ := conversionPlans.LoadOrStore(t, newConversionPlan(t))
+	return plan.(*conversionPlan)
+}
+
+func newConversionPlan(t reflect.Type) *conversionPlan {
+	plan := &conversionPlan{
+		LValue: t.Implements(refTypeLuaLValue),
+	}
+	if t.Kind() == reflect.Func {
+		plan.In = make([]reflect.Type, t.NumIn())
+		for i := range plan.In {
+			plan.In[i] = t.In(i)
+		}
+		if t.IsVariadic() {
+			plan.In[len(plan.In)-1] = plan.In[len(plan.In)-1].Elem()
+		}
+		plan.Bypass = funcIsBypass(t)
+	}
+	return plan
+}
+
+// hint returns the type that the i-th (0-based) argument of a call is
+// converted to. The plan must be the plan of a function type.
+func (plan *conversionPlan) hint(i int) reflect.Type {
+	if i >= len(plan.In) {
+		return plan.In[len(plan.In)-1]
+	}
+	return plan.In[i]
+}
+
+// PrecomputeTypes computes the type information that luar needs to convert
+// values of the types of the given values and to call their methods, so that
+// the first conversion in each LState is faster. The information is shared by
+// all LStates of the process and does not depend on their configuration.
+// Pointer types are precomputed together with their element type, and
+// interface types can be given as nil pointers to them.
+//
+// Example:
+//  func init() {
+//    luar.PrecomputeTypes(Claim{}, Member{}, (*ClaimReader)(nil))
+//  }
+func PrecomputeTypes(values ...interface{}) {
+	for _, value := range values {
+		t := reflect.TypeOf(value)
+		if t == nil {
+			continue
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		precomputeType(t)
+		if t.Kind() != reflect.Interface {
+			precomputeType(reflect.PtrTo(t))
+		}
+	}
+}
+
+// precomputeType computes the descriptor of t and the conversion plans of t
+// and of its methods and their arguments.
+func precomputeType(t reflect.Type) {
+	planConversion(t)
+	for _, method := range describeType(t).Methods {
+		plan := planConversion(method.Method.Type)
+		for _, in := range plan.In {
+			planConversion(in)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package luar
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type DescriptorTestBase struct {
+	ID     int
+	hidden int
+}
+
+type DescriptorTestClaim struct {
+	DescriptorTestBase
+	*DescriptorTestMember
+	Amount float64
+}
+
+type DescriptorTestMember struct {
+	Name string
+}
+
+func (c DescriptorTestClaim) Total() float64 {
+	return c.Amount
+}
+
+func (c *DescriptorTestClaim) SetAmount(amount float64) {
+	c.Amount = amount
+}
+
+type DescriptorTestReader interface {
+	Total() float64
+}
+
+func Test_descriptor(t *testing.T) {
+	claimType := reflect.TypeOf(DescriptorTestClaim{})
+	desc := describeType(claimType)
+	if describeType(claimType) != desc {
+		t.Fatal("expecting descriptors to be shared")
+	}
+
+	var fields [][]int
+	for _, field := range desc.Fields {
+		fields = append(fields, field.Index)
+	}
+	expected := [][]int{{0}, {1}, {2}, {0, 0}, {1, 0}}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("expecting field indexes %v, got %v", expected, fields)
+	}
+	if !desc.Fields[0].Embedded || !desc.Fields[1].Embedded || desc.Fields[2].Embedded {
+		t.Fatal("unexpected embedded fields")
+	}
+	if owner := desc.field([]int{1, 0}).Owner; owner != reflect.TypeOf(DescriptorTestMember{}) {
+		t.Fatalf("unexpected owner %v", owner)
+	}
+
+	if len(desc.Methods) != 1 || desc.Methods[0].Method.Name != "Total" || desc.Methods[0].Kind != MethodPure {
+		t.Fatalf("unexpected methods %+v", desc.Methods)
+	}
+	ptrDesc := describeType(reflect.PtrTo(claimType))
+	if method := ptrDesc.method("SetAmount"); method == nil || method.Kind != MethodMutating {
+		t.Fatalf("unexpected pointer method %+v", method)
+	}
+}
+
+func Test_descriptor_precompute(t *testing.T) {
+	PrecomputeTypes(&DescriptorTestMember{}, (*DescriptorTestReader)(nil), nil)
+
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf(DescriptorTestMember{}),
+		reflect.TypeOf(&DescriptorTestMember{}),
+		reflect.TypeOf((*DescriptorTestReader)(nil)).Elem(),
+	} {
+		if _, ok := typeDescriptors.Load(typ); !ok {
+			t.Fatalf("expecting %v to be precomputed", typ)
+		}
+	}
+}
+
+func Test_descriptor_conversion_plan(t *testing.T) {
+	fnType := reflect.TypeOf(func(a int, rest ...string) {})
+	plan := planConversion(fnType)
+	if planConversion(fnType) != plan {
+		t.Fatal("expecting conversion plans to be shared")
+	}
+	if plan.Bypass || plan.LValue {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	for i, expected := range []reflect.Type{refTypeInt, reflect.TypeOf(""), reflect.TypeOf("")} {
+		if hint := plan.hint(i); hint != expected {
+			t.Fatalf("expecting hint %v for argument %d, got %v", expected, i, hint)
+		}
+	}
+
+	if !planConversion(reflect.TypeOf(func(*LState) int { return 0 })).Bypass {
+		t.Fatal("expecting bypass plan")
+	}
+	if !planConversion(refTypeLuaLValue).LValue || !planConversion(reflect.TypeOf(lua.LString(""))).LValue {
+		t.Fatal("expecting lua.LValue plans")
+	}
+
+	PrecomputeTypes(DescriptorTestClaim{})
+	setAmount, _ := reflect.TypeOf(&DescriptorTestClaim{}).MethodByName("SetAmount")
+	if _, ok := conversionPlans.Load(setAmount.Type); !ok {
+		t.Fatal("expecting method plans to be precomputed")
+	}
+}
+
+func Test_descriptor_concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			L := lua.NewState()
+			defer L.Close()
+
+			L.SetGlobal("c", New(L, &DescriptorTestClaim{
+				DescriptorTestMember: &DescriptorTestMember{Name: "Tim"},
+			}))
+			if err := L.DoString(`
+				c:SetAmount(4)
+				assert(c:Total() == 4 and c.Name == "Tim" and c.ID == 0 and c.hidden == nil)
+			`); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
@@ -50,7 +50,10 @@
 //
 // A function that has the signature func(*luar.LState) int can bypass the
 // automatic argument and return value conversion (see luar.LState
-// documentation for example).
+// documentation for example). Such functions can use Check and Opt to
+// convert their arguments like those of regular functions, Unwrap or As to get
+// the Go value of a luar argument, and Push to push results. LState.Options
+// returns the ReflectOptions of the receiver or of the first luar argument.
 //
 // A special conversion case happens when function returns a lua.LValue slice.
 // In that case, luar automatically unpacks the slice.
@@ -65,6 +68,21 @@
 //  print(x) -- prints "Hello"
 //  print(y) -- prints "2.5"
 //
+// Cross-cutting behaviour, such as logging, timing or rate limiting, can be
+// added to every call of a Go function or method using Config.Use. Each
+// middleware receives a CallInfo with the function name, receiver type,
+// arguments and results, and can short-circuit the call. Metrics provides a
+// middleware that counts calls, errors, time and allocations per function and
+// receiver type, and labels CPU profiles with the called function.
+// Recorder and Replayer provide middlewares that record the Go calls made by a
+// script as JSON lines, and feed the recorded results back to the script
+// without calling the Go functions.
+//
+// Functions with common signatures of strings, numbers and booleans, such as
+// func(string) bool or func(float64, float64) float64, are called without
+// reflection while no middleware or access policy is configured. Fast paths
+// for other signatures can be added with RegisterFastPath.
+//
 // Maps
 //
 // Maps can be accessed and modified like a normal Lua table. The map's length
@@ -217,6 +235,8 @@
 // Any behavior is inherited by objects that are accessed through the
 // original reflected item. For example, functions reflected with
 // TransparentPointers will return objects that transparently dereference.
+// Likewise, methods called on a reflected value, and values received from a
+// reflected channel, inherit the options of that value.
 //
 // Type methods
 //
@@ -281,6 +301,12 @@
 //  Slice     Length (opt), Capacity (opt)   Slice
 //  Default   None                           Pointer to the newly allocated value
 //
+// ReflectOptions passed to NewType are applied to every created value.
+//
+// The sizes that Lua code can request are bounded by the MaxSliceLength,
+// MaxChanBuffer and MaxAllocBytes limits of Config. Exceeding a limit raises a
+// Lua error, which can be caught using pcall.
+//
 // Example:
 //  type Person struct {
 //    Name string
@@ -312,6 +338,177 @@
 // Calling a pointer method on a struct is invalid when using immutability,
 // since pointer methods can modify the struct's internal state.
 //
+// Value methods can still modify state that is shared through pointer, map or
+// slice fields. To prevent this, a type can implement PureMethods to list the
+// methods that are safe to call, or Config.MethodPolicy can classify methods
+// as pure or mutating. Only pure methods can be called on immutable values.
+//
+// Example:
+//  type Counter struct {
+//    counts map[string]int
+//  }
+//  func (c Counter) Get(name string) int { return c.counts[name] }
+//  func (c Counter) Add(name string)     { c.counts[name]++ }
+//  func (Counter) LuarPureMethods() []string {
+//    return []string{"Get"}
+//  }
+//  ---
+//  print(counter:Get("a")) -- fine
+//  counter:Add("a")        -- raises an error when counter is immutable
+//
+// Immutable views can also be created at runtime using Freeze, or from Lua
+// using the freeze function of the luar module (see Loader). The frozen value
+// refers to the same Go value, but cannot be used to modify it.
+//
+// Example:
+//  L.PreloadModule("luar", Loader)
+//  ---
+//  local luar = require("luar")
+//  local readonly = luar.freeze(tim)
+//  print(luar.isfrozen(readonly)) -- prints "true"
+//  readonly.Name = "Bob"          -- raises an error!
+//
+// Access policies
+//
+// Config.AccessPolicy can be used to centrally decide which Go values Lua code
+// may touch. It is called with the accessed type, the Go name of the field or
+// method (if any) and the kind of operation before every access. Returning an
+// error denies the access and raises a Lua error.
+//
+// Example:
+//  GetConfig(L).AccessPolicy = func(ctx AccessContext) error {
+//    if ctx.Member == "SSN" {
+//      return errors.New("access to SSN denied")
+//    }
+//    return nil
+//  }
+//
+// Interface views
+//
+// NewAs exposes a value through an interface type. Only the methods declared
+// on the interface can be called from Lua; fields, other methods and pointer
+// operators of the concrete type are not accessible. When passed back to Go,
+// the value only converts to the interface type.
+//
+// Example:
+//  type ClaimReader interface {
+//    Claim(id int) *Claim
+//  }
+//  L.SetGlobal("claims", NewAs(L, store, reflect.TypeOf((*ClaimReader)(nil)).Elem()))
+//  ---
+//  local c = claims:Claim(12)  -- fine
+//  claims:DeleteClaim(12)      -- raises an error; not part of ClaimReader
+//
+// Opaque values
+//
+// Values can be handed to Lua as opaque handles by setting
+// ReflectOptions.Opaque. Opaque values have no accessible fields, methods or
+// operators, but they can be passed back to Go functions unchanged.
+//
+// Config.ExposedTypes restricts the types that are reflected with fields,
+// methods and operators. Values of any other type, including values returned
+// from functions and methods, are converted to opaque values.
+//
+// Example:
+//  GetConfig(L).ExposedTypes = ExposeTypes(Claim{})
+//  L.SetGlobal("claim", New(L, &Claim{}))
+//  ---
+//  local db = claim:DB() -- *sql.DB is not exposed, so db is opaque
+//  print(db.Stats)       -- raises an error!
+//
+// Bindings
+//
+// The fields and methods of types whose declaration cannot be changed, such as
+// generated or third-party types, can be hidden, renamed or made read-only
+// with Config.BindType or Bind. Bindings can also be loaded from a JSON file
+// (see Config.LoadBindings and BindingFile).
+//
+// Example:
+//  Bind[vendor.Claim](GetConfig(L)).
+//    Field("InternalID").Hide().
+//    Field("Amt").As("amount").ReadOnly().
+//    Method("Close").Hide()
+//
+// The metatable of a type is built from the configuration when a value of the
+// type is first converted. Config.Invalidate and Config.Reset rebuild
+// metatables after the configuration has changed. After Config.Freeze, the
+// configuration no longer changes: its methods that would change it panic, and
+// so does its next use after one of its fields is assigned.
+//
+// The reflection work that does not depend on the configuration, i.e. the
+// fields and methods of types and the plans for converting Lua values to Go
+// types and function arguments, is shared by all LStates of the process.
+// PrecomputeTypes performs it ahead of time, e.g. before a pool of LStates is
+// created. Metatables and the Lua functions that wrap methods depend on the
+// configuration, so they are still built once per LState.
+//
+// Fields and methods of a struct type can also be accessed without
+// reflection, using accessors registered with Config.RegisterStatic. The
+// luar-gen command generates them from the Go source of the type:
+//  go run github.com/oscarhealth/gopher-luar/cmd/luar-gen -type Claim,Member
+//
+// Introspection
+//
+// The luar module (see Loader) also provides functions that describe luar
+// values to Lua code, such as typeof, kind, fields and methods. The returned
+// names respect Config.FieldNames, Config.MethodNames and the access policy.
+//
+// Values that implement neither fmt.Stringer nor error are converted to
+// strings by tostring as their type and a short summary of their fields or
+// elements (see Config.Tostring). The dump function of the luar module shows
+// nested values in full.
+//
+// Example:
+//  local luar = require("luar")
+//  print(luar.typeof(tim))                     -- prints "*main.Person"
+//  print(table.concat(luar.fields(tim), ", ")) -- prints "Name, Age"
+//  print(tim)                                  -- prints `Person{Name="Tim", Age=30}`
+//  print(luar.dump(tim))
+//
+// Identity
+//
+// By default, every conversion of a Go value creates a new userdata, so
+// rawequal returns false for two conversions of the same pointer. If
+// Config.IdentityCache is set, conversions of the same Go pointer, map or
+// channel with the same options return the same userdata, which can then be
+// used as a table key.
+//
+// Lua's == operator compares the underlying Go values, using their Equal
+// method if they have one. Config.Equality can select deep, shallow or
+// identity comparison per type.
+//
+// Example:
+//  GetConfig(L).IdentityCache = true
+//  ---
+//  local seen = {}
+//  seen[claim.Member] = true
+//  print(seen[claim.Member]) -- prints "true"
+//
+// Errors
+//
+// Go errors can be raised as Lua errors using Raise. If
+// Config.RaiseReturnedErrors is set, non-nil errors returned from Go functions
+// are raised as well. If a Go function called from Lua panics, the panic is
+// recovered and raised as a Lua error with the message "panic in <function>:
+// <value>". Config.PanicHandler can be used to report such panics.
+//
+// The Lua error object of errors raised using Raise is a userdata that
+// converts to the error message using tostring, and it keeps carrying the Go
+// error when Lua code catches and rethrows it using error(e). Panics and
+// denials of the access policy are raised as messages, like other luar
+// errors. On the Go side, WrapError makes the Go error (a *PanicError for
+// panics) accessible to errors.Is and errors.As.
+//
+// Example:
+//  err := WrapError(L.DoString(script))
+//  if errors.Is(err, ErrNotFound) {
+//    ...
+//  }
+//  var panicErr *PanicError
+//  if errors.As(err, &panicErr) {
+//    log.Printf("%s panicked: %v", panicErr.Func, panicErr.Value)
+//  }
+//
 // Thread safety
 //
 // This package accesses and modifies the Lua state's registry. This happens
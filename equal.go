@@ -0,0 +1,168 @@
+package luar
+
+import (
+	"reflect"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// EqualityMode describes how Lua's == operator compares two luar values.
+type EqualityMode int
+
+const (
+	// EqualityDefault leaves the comparison to the default behaviour.
+	EqualityDefault EqualityMode = iota
+	// EqualityDeep compares the Go values using reflect.DeepEqual.
+	EqualityDeep
+	// EqualityIdentity compares pointers, channels, maps and slices by
+	// address. Other values are compared like EqualityShallow does.
+	EqualityIdentity
+	// EqualityShallow compares the Go values field by field, or element by
+	// element for arrays, following pointers only at the top level. Fields
+	// that are pointers, channels, functions, maps or slices are compared by
+	// address.
+	EqualityShallow
+)
+
+var refTypeBool = reflect.TypeOf(false)
+
+func (c *Config) equalityMode(t reflect.Type) EqualityMode {
+	if equality := c.settings().Equality; equality != nil {
+		return equality(t)
+	}
+	return EqualityDefault
+}
+
+// valuesEqual compares the Go values of two luar userdata. If
+// Config.Equality returns EqualityDefault for the type of the first value,
+// the Equal method of the first value is used if it has one, and def
+// otherwise.
+func valuesEqual(L *lua.LState, ud1, ud2 *lua.LUserData, def EqualityMode) bool {
+	v1 := userDataValue(ud1)
+	v2 := userDataValue(ud2)
+	if !v1.IsValid() || !v2.IsValid() {
+		return v1.IsValid() == v2.IsValid()
+	}
+
+	if equal, ok := customEqual(L, v1, v2); ok {
+		return equal
+	}
+	return equalWithMode(v1, v2, def)
+}
+
+// userDataValue returns the Go value of ud, which is the concrete value for
+// values created by NewAs.
+func userDataValue(ud *lua.LUserData) reflect.Value {
+	if refIface, ok := ud.Value.(*reflectedInterface); ok {
+		return reflect.ValueOf(refIface.Interface)
+	}
+	return reflect.ValueOf(ud.Value)
+}
+
+// callEqualMethod calls the method "Equal(other T) bool" of v1 with v2,
+// converted to T. false is returned if v1 has no such method.
+func callEqualMethod(L *lua.LState, v1, v2 reflect.Value) (equal, ok bool) {
+	method := v1.MethodByName("Equal")
+	if !method.IsValid() {
+		return false, false
+	}
+	t := method.Type()
+	if t.NumIn() != 1 || t.NumOut() != 1 || t.IsVariadic() || t.Out(0) != refTypeBool {
+		return false, false
+	}
+	arg, ok := convertValue(v2, t.In(0))
+	if !ok {
+		return false, true
+	}
+	ret, err := callFuncRecover(L, method, []reflect.Value{arg})
+	if err != nil {
+		raiseCallError(L, err)
+	}
+	return ret[0].Bool(), true
+}
+
+func equalWithMode(v1, v2 reflect.Value, mode EqualityMode) bool {
+	if v1.Type() != v2.Type() {
+		return false
+	}
+	switch mode {
+	case EqualityIdentity:
+		switch v1.Kind() {
+		case reflect.Chan, reflect.Map, reflect.Ptr, reflect.Slice:
+			return addressEqual(v1, v2)
+		}
+		return inlineEqual(v1, v2)
+	case EqualityShallow:
+		if v1.Kind() == reflect.Ptr {
+			if v1.Pointer() == v2.Pointer() {
+				return true
+			}
+			if v1.IsNil() || v2.IsNil() {
+				return false
+			}
+			v1, v2 = v1.Elem(), v2.Elem()
+		}
+		return inlineEqual(v1, v2)
+	default:
+		return reflect.DeepEqual(v1.Interface(), v2.Interface())
+	}
+}
+
+func addressEqual(v1, v2 reflect.Value) bool {
+	if v1.Pointer() != v2.Pointer() {
+		return false
+	}
+	return v1.Kind() != reflect.Slice || v1.Len() == v2.Len()
+}
+
+// inlineEqual compares two values of the same type without following
+// pointers.
+func inlineEqual(v1, v2 reflect.Value) bool {
+	switch v1.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v1.NumField(); i++ {
+			if !inlineEqual(v1.Field(i), v2.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		for i := 0; i < v1.Len(); i++ {
+			if !inlineEqual(v1.Index(i), v2.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		if v1.IsNil() || v2.IsNil() {
+			return v1.IsNil() == v2.IsNil()
+		}
+		return v1.Elem().Type() == v2.Elem().Type() && inlineEqual(v1.Elem(), v2.Elem())
+	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
+		return addressEqual(v1, v2)
+	case reflect.Bool:
+		return v1.Bool() == v2.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v1.Int() == v2.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v1.Uint() == v2.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v1.Float() == v2.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return v1.Complex() == v2.Complex()
+	case reflect.String:
+		return v1.String() == v2.String()
+	}
+	return false
+}
+
+// customEqual compares two values using the mode returned by Config.Equality
+// or, for EqualityDefault, using the Equal method of v1. false is returned if
+// neither applies.
+func customEqual(L *lua.LState, v1, v2 reflect.Value) (equal, ok bool) {
+	mode := GetConfig(L).equalityMode(v1.Type())
+	if mode == EqualityDefault {
+		return callEqualMethod(L, v1, v2)
+	}
+	return equalWithMode(v1, v2, mode), true
+}
@@ -0,0 +1,101 @@
+package luar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type EqualTestClaim struct {
+	ID    int
+	Notes []string
+}
+
+type EqualTestCode struct {
+	Code string
+}
+
+func (c EqualTestCode) Equal(other EqualTestCode) bool {
+	return strings.EqualFold(c.Code, other.Code)
+}
+
+type EqualTestCodes []string
+
+func (c EqualTestCodes) Equal(other EqualTestCodes) bool {
+	return len(c) == len(other)
+}
+
+func Test_equal_options(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	claim := &EqualTestClaim{ID: 1}
+
+	L.SetGlobal("a", New(L, claim))
+	L.SetGlobal("b", New(L, claim, ReflectOptions{Immutable: true}))
+	L.SetGlobal("c", New(L, &EqualTestClaim{ID: 1}))
+	L.SetGlobal("d", New(L, &EqualTestClaim{ID: 2}))
+
+	testReturn(t, L, `return a == b, a == c, a == d`, "true", "true", "false")
+}
+
+func Test_equal_method(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.SetGlobal("a", New(L, EqualTestCode{Code: "abc"}))
+	L.SetGlobal("b", New(L, &EqualTestCode{Code: "ABC"}))
+	L.SetGlobal("c", New(L, EqualTestCode{Code: "abd"}))
+	L.SetGlobal("s1", New(L, EqualTestCodes{"a"}))
+	L.SetGlobal("s2", New(L, EqualTestCodes{"b"}))
+
+	testReturn(t, L, `return a == b, b == a, a == c`, "true", "true", "false")
+	testReturn(t, L, `return s1 == s2`, "true")
+}
+
+func Test_equal_modes(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	mode := EqualityIdentity
+	GetConfig(L).Equality = func(t reflect.Type) EqualityMode {
+		if t == reflect.TypeOf(&EqualTestClaim{}) {
+			return mode
+		}
+		return EqualityDefault
+	}
+
+	notes := []string{"x"}
+	claim := &EqualTestClaim{ID: 1, Notes: notes}
+
+	L.SetGlobal("a", New(L, claim))
+	L.SetGlobal("b", New(L, claim, ReflectOptions{Immutable: true}))
+	L.SetGlobal("c", New(L, &EqualTestClaim{ID: 1, Notes: notes}))
+	L.SetGlobal("d", New(L, &EqualTestClaim{ID: 1, Notes: []string{"x"}}))
+
+	testReturn(t, L, `return a == b, a == c, a == d`, "true", "false", "false")
+	mode = EqualityShallow
+	testReturn(t, L, `return a == b, a == c, a == d`, "true", "true", "false")
+	mode = EqualityDeep
+	testReturn(t, L, `return a == b, a == c, a == d`, "true", "true", "true")
+}
+
+func Test_equal_ptr(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	x, y := 1, 1
+
+	L.SetGlobal("x", New(L, &x))
+	L.SetGlobal("x2", New(L, &x))
+	L.SetGlobal("y", New(L, &y))
+
+	testReturn(t, L, `return x == x2, x == y`, "true", "false")
+
+	GetConfig(L).Equality = func(t reflect.Type) EqualityMode {
+		return EqualityShallow
+	}
+	testReturn(t, L, `return x == x2, x == y`, "true", "true")
+}
@@ -0,0 +1,157 @@
+package luar
+
+import (
+	"fmt"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// PanicError is raised as a Lua error when a Go function called from Lua
+// panics.
+type PanicError struct {
+	// The value passed to panic.
+	Value interface{}
+	// The name of the Go function that was called from Lua.
+	Func string
+	// The Go stack of the panicking goroutine, if Config.PanicStack is set.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s: %v", e.Func, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, or nil otherwise.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// ScriptError is returned by WrapError. It is a *lua.ApiError that unwraps to
+// the Go error that was raised through luar, if any.
+type ScriptError struct {
+	*lua.ApiError
+	// The Go error carried by the Lua error object, or nil if the Lua error
+	// was not raised from a Go error.
+	Err error
+}
+
+func (e *ScriptError) Error() string {
+	if errorFromLValue(e.Object) == nil {
+		// The error object is a message, e.g. of an access denial or a
+		// panic, which is more useful than the message of Err.
+		return e.ApiError.Error()
+	}
+	if e.StackTrace != "" {
+		return e.Err.Error() + "\n" + e.StackTrace
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the Go error carried by the Lua error object.
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// WrapError wraps an error returned by gopher-lua (e.g. from L.PCall or
+// L.DoString) so that Go errors raised through luar can be inspected using
+// errors.Is and errors.As. These are the errors raised using Raise, and the
+// errors of the access policy and the *PanicError of panics, as long as Lua
+// code did not catch them. Errors that are not a *lua.ApiError are returned
+// unchanged.
+//
+// Example:
+//  err := luar.WrapError(L.DoString(script))
+//  var panicErr *luar.PanicError
+//  if errors.As(err, &panicErr) {
+//    report(panicErr.Value, panicErr.Stack)
+//  }
+func WrapError(err error) error {
+	apiErr, ok := err.(*lua.ApiError)
+	if !ok {
+		return err
+	}
+	err = errorFromLValue(apiErr.Object)
+	if err == nil {
+		err = apiErr.Cause
+	}
+	return &ScriptError{
+		ApiError: apiErr,
+		Err:      err,
+	}
+}
+
+// errorFromLValue returns the Go error carried by lv, or nil.
+func errorFromLValue(lv lua.LValue) error {
+	ud, ok := lv.(*lua.LUserData)
+	if !ok {
+		return nil
+	}
+	refIface, ok := ud.Value.(*reflectedInterface)
+	if !ok {
+		return nil
+	}
+	err, _ := refIface.Interface.(error)
+	return err
+}
+
+// Raise raises err as a Lua error. The error object is a userdata that carries
+// err, so that it can be retrieved using WrapError, even if Lua code catches
+// and rethrows the error using error(e). In Lua, tostring(e) returns the error
+// message, and e can be passed to Go functions that accept an error.
+//
+// Example:
+//  func find(L *luar.LState) int {
+//    claim, err := db.Find(L.CheckInt(1))
+//    if err != nil {
+//      luar.Raise(L.LState, err)
+//    }
+//    ...
+//  }
+func Raise(L *lua.LState, err error) {
+	ud := L.NewUserData()
+	ud.Value = newReflectedInterface(err, defaultReflectOptions())
+	ud.Metatable = getErrorMetatable(L)
+	L.Error(ud, 1)
+}
+
+// raiseMessage raises message as a Lua error like L.RaiseError, so that the
+// error reads like the other errors of luar, and sets the Cause of the
+// resulting *lua.ApiError to err for WrapError. Unlike with Raise, err is
+// lost if Lua code catches the error.
+func raiseMessage(L *lua.LState, err error, message string) {
+	defer func() {
+		rec := recover()
+		if apiErr, ok := rec.(*lua.ApiError); ok && apiErr.Cause == nil {
+			apiErr.Cause = err
+		}
+		panic(rec)
+	}()
+	L.RaiseError("%s", message)
+}
+
+// raiseCallError raises the error of a call of a Go function: a *PanicError
+// as a message (see raiseMessage), and other errors using Raise.
+func raiseCallError(L *lua.LState, err error) {
+	if panicErr, ok := err.(*PanicError); ok {
+		raiseMessage(L, panicErr, panicErr.Error())
+	}
+	Raise(L, err)
+}
+
+func errorTostring(L *lua.LState) int {
+	ud := L.CheckUserData(1)
+	if err := errorFromLValue(ud); err != nil {
+		L.Push(lua.LString(err.Error()))
+	} else {
+		L.Push(lua.LString(ud.String()))
+	}
+	return 1
+}
+
+func errorConcat(L *lua.LState) int {
+	L.Push(lua.LString(L.ToStringMeta(L.CheckAny(1)).String() + L.ToStringMeta(L.CheckAny(2)).String()))
+	return 1
+}
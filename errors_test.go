@@ -0,0 +1,160 @@
+package luar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_errors_panic(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	var handled *PanicError
+	config := GetConfig(L)
+	config.PanicStack = true
+	config.PanicHandler = func(L *lua.LState, err *PanicError) {
+		handled = err
+	}
+
+	var p *StructTestPerson
+	fn := func() {
+		p.IncreaseAge()
+	}
+
+	L.SetGlobal("fn", New(L, fn))
+
+	err := WrapError(L.DoString(`fn()`))
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expecting *PanicError, got %v", err)
+	}
+	if panicErr != handled {
+		t.Fatal("expecting PanicHandler to be called with the raised error")
+	}
+	if !strings.Contains(panicErr.Func, "Test_errors_panic") {
+		t.Fatalf("unexpected function name %s", panicErr.Func)
+	}
+	if !strings.Contains(string(panicErr.Stack), "IncreaseAge") {
+		t.Fatal("expecting Go stack to be captured")
+	}
+	var runtimeErr interface {
+		RuntimeError()
+	}
+	if !errors.As(err, &runtimeErr) {
+		t.Fatal("expecting the panic value to be a runtime error")
+	}
+	if !strings.Contains(err.Error(), "invalid memory address or nil pointer dereference") {
+		t.Fatalf("unexpected error message %s", err)
+	}
+
+	testReturn(t, L, `local ok, err = pcall(fn); return ok, tostring(err):find("panic in") ~= nil`, "false", "true")
+
+	// Callers that do not use WrapError get the message of the panic.
+	err = L.DoString(`fn()`)
+	if s := err.Error(); !strings.HasPrefix(s, "<string>:1: panic in ") || !strings.Contains(s, "nil pointer dereference") {
+		t.Fatalf("unexpected error message %s", s)
+	}
+}
+
+func Test_errors_panicmethod(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	s := TestStructPureMethods{}
+
+	L.SetGlobal("s", New(L, s))
+
+	err := WrapError(L.DoString(`s:First()`))
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || !strings.HasSuffix(panicErr.Func, ".TestStructPureMethods.First") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	testReturn(t, L, `local ok, err = pcall(s.First, s); return type(err), err:find("panic in ", 1, true) ~= nil`, "string", "true")
+}
+
+func Test_errors_bypass(t *testing.T) {
+	// Lua errors raised by bypass functions are not panics
+	L := lua.NewState()
+	defer L.Close()
+
+	fn := func(L *LState) int {
+		L.RaiseError("bypass error")
+		return 0
+	}
+
+	L.SetGlobal("fn", New(L, fn))
+
+	err := WrapError(L.DoString(`fn()`))
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		t.Fatal("unexpected *PanicError")
+	}
+	testError(t, L, `fn()`, "bypass error")
+}
+
+var errTestNotFound = errors.New("not found")
+
+func Test_errors_identity(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	find := func(L *LState) int {
+		Raise(L.LState, fmt.Errorf("claim %d: %w", L.CheckInt(1), errTestNotFound))
+		return 0
+	}
+	isNotFound := func(err error) bool {
+		return errors.Is(err, errTestNotFound)
+	}
+
+	L.SetGlobal("find", New(L, find))
+	L.SetGlobal("isNotFound", New(L, isNotFound))
+
+	err := WrapError(L.DoString(`find(12)`))
+	if !errors.Is(err, errTestNotFound) {
+		t.Fatalf("expecting errTestNotFound, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "claim 12: not found") {
+		t.Fatalf("unexpected error message %s", err)
+	}
+
+	err = WrapError(L.DoString(`
+		local ok, e = pcall(find, 12)
+		assert(not ok)
+		assert(tostring(e) == "claim 12: not found")
+		assert(isNotFound(e))
+		error(e)
+	`))
+	if !errors.Is(err, errTestNotFound) {
+		t.Fatalf("expecting rethrown errTestNotFound, got %v", err)
+	}
+
+	err = WrapError(L.DoString(`error("plain")`))
+	if scriptErr, ok := err.(*ScriptError); !ok || scriptErr.Err != nil || !strings.Contains(err.Error(), "plain") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func Test_errors_returned(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	GetConfig(L).RaiseReturnedErrors = true
+
+	find := func(id int) (string, error) {
+		if id != 1 {
+			return "", errTestNotFound
+		}
+		return "Tim", nil
+	}
+
+	L.SetGlobal("find", New(L, find))
+
+	testReturn(t, L, `return find(1)`, "Tim")
+	if err := WrapError(L.DoString(`find(2)`)); !errors.Is(err, errTestNotFound) {
+		t.Fatalf("expecting errTestNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package luar
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// FastPath calls a Go function of a particular signature without going
+// through reflection. fn is the Go function, and its arguments are the values
+// on the stack of L. FastPath pushes the results onto the stack and returns
+// their number, like a lua.LGFunction.
+//
+// If the arguments cannot be handled (e.g. their number or Lua types do not
+// match the signature), FastPath must return false without modifying the
+// stack; the function is then called through the regular path, which converts
+// the arguments or raises an error.
+type FastPath func(L *lua.LState, fn interface{}) (int, bool)
+
+// fastPaths maps function types to their FastPath.
+var fastPaths sync.Map
+
+// RegisterFastPath registers a fast path for Go functions that have the same
+// type as sample. Fast paths are shared by all LStates of the process and
+// replace the built-in fast path for the type, if there is one. They are used
+// by functions created afterwards.
+//
+// Fast paths are only used for functions, not for methods, and only while the
+// configuration of the LState has neither middlewares nor an access policy.
+// The results of fast paths must be the same as those of the regular path.
+//
+// Example:
+//  luar.RegisterFastPath(func(*Claim) bool { return false }, func(L *lua.LState, fn interface{}) (int, bool) {
+//    claim, ok := luar.As[*Claim](L.Get(1))
+//    if !ok || L.GetTop() != 1 {
+//      return 0, false
+//    }
+//    L.Push(lua.LBool(fn.(func(*Claim) bool)(claim)))
+//    return 1, true
+//  })
+func RegisterFastPath(sample interface{}, path FastPath) {
+	t := reflect.TypeOf(sample)
+	if t == nil || t.Kind() != reflect.Func {
+		panic("luar: RegisterFastPath called with a non-function sample")
+	}
+	fastPaths.Store(t, path)
+}
+
+func getFastPath(t reflect.Type) FastPath {
+	if path, ok := fastPaths.Load(t); ok {
+		return path.(FastPath)
+	}
+	return nil
+}
+
+// fastFunc is the third upvalue of functions that have a fast path, or of
+// methods that have a static caller (see StaticType).
+type fastFunc struct {
+	config *Config
+	ref    reflect.Value
+	fn     interface{}
+	path   FastPath
+}
+
+func funcFast(L *lua.LState) int {
+	fast := L.Get(lua.UpvalueIndex(3)).(*lua.LUserData).Value.(*fastFunc)
+	if fast.config.handler != nil || fast.config.settings().AccessPolicy != nil {
+		return funcRegular(L)
+	}
+	if info := getMethodInfo(L); info != nil && !info.Pure && valueOptions(L.Get(1)).Immutable {
+		// The regular path raises the error.
+		return funcRegular(L)
+	}
+	if n, ok := fast.call(L); ok {
+		return n
+	}
+	return funcRegular(L)
+}
+
+// call calls the fast path. Panics of the function are raised like in
+// callFuncRecover.
+func (fast *fastFunc) call(L *lua.LState) (n int, ok bool) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			raiseCallError(L, recoveredPanic(L, fast.ref, rec))
+		}
+	}()
+	return fast.path(L, fast.fn)
+}
+
+func fastString(L *lua.LState, idx int) (string, bool) {
+	s, ok := L.Get(idx).(lua.LString)
+	return string(s), ok
+}
+
+func fastNumber(L *lua.LState, idx int) (float64, bool) {
+	n, ok := L.Get(idx).(lua.LNumber)
+	return float64(n), ok
+}
+
+func fastInt(L *lua.LState, idx int) (int, bool) {
+	n, ok := L.Get(idx).(lua.LNumber)
+	return int(n), ok
+}
+
+func fastBool(L *lua.LState, idx int) (bool, bool) {
+	b, ok := L.Get(idx).(lua.LBool)
+	return bool(b), ok
+}
+
+func init() {
+	RegisterFastPath(func() {}, func(L *lua.LState, fn interface{}) (int, bool) {
+		if L.GetTop() != 0 {
+			return 0, false
+		}
+		fn.(func())()
+		return 0, true
+	})
+	RegisterFastPath(func() bool { return false }, func(L *lua.LState, fn interface{}) (int, bool) {
+		if L.GetTop() != 0 {
+			return 0, false
+		}
+		L.Push(lua.LBool(fn.(func() bool)()))
+		return 1, true
+	})
+	RegisterFastPath(func() float64 { return 0 }, func(L *lua.LState, fn interface{}) (int, bool) {
+		if L.GetTop() != 0 {
+			return 0, false
+		}
+		L.Push(lua.LNumber(fn.(func() float64)()))
+		return 1, true
+	})
+	RegisterFastPath(func() string { return "" }, func(L *lua.LState, fn interface{}) (int, bool) {
+		if L.GetTop() != 0 {
+			return 0, false
+		}
+		L.Push(lua.LString(fn.(func() string)()))
+		return 1, true
+	})
+	RegisterFastPath(func(string) {}, func(L *lua.LState, fn interface{}) (int, bool) {
+		s, ok := fastString(L, 1)
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		fn.(func(string))(s)
+		return 0, true
+	})
+	RegisterFastPath(func(string) bool { return false }, func(L *lua.LState, fn interface{}) (int, bool) {
+		s, ok := fastString(L, 1)
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		L.Push(lua.LBool(fn.(func(string) bool)(s)))
+		return 1, true
+	})
+	RegisterFastPath(func(string) string { return "" }, func(L *lua.LState, fn interface{}) (int, bool) {
+		s, ok := fastString(L, 1)
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		L.Push(lua.LString(fn.(func(string) string)(s)))
+		return 1, true
+	})
+	RegisterFastPath(func(string) float64 { return 0 }, func(L *lua.LState, fn interface{}) (int, bool) {
+		s, ok := fastString(L, 1)
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		L.Push(lua.LNumber(fn.(func(string) float64)(s)))
+		return 1, true
+	})
+	RegisterFastPath(func(string, string) bool { return false }, func(L *lua.LState, fn interface{}) (int, bool) {
+		a, okA := fastString(L, 1)
+		b, okB := fastString(L, 2)
+		if !okA || !okB || L.GetTop() != 2 {
+			return 0, false
+		}
+		L.Push(lua.LBool(fn.(func(string, string) bool)(a, b)))
+		return 1, true
+	})
+	RegisterFastPath(func(string, string) string { return "" }, func(L *lua.LState, fn interface{}) (int, bool) {
+		a, okA := fastString(L, 1)
+		b, okB := fastString(L, 2)
+		if !okA || !okB || L.GetTop() != 2 {
+			return 0, false
+		}
+		L.Push(lua.LString(fn.(func(string, string) string)(a, b)))
+		return 1, true
+	})
+	RegisterFastPath(func(float64) bool { return false }, func(L *lua.LState, fn interface{}) (int, bool) {
+		n, ok := fastNumber(L, 1)
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		L.Push(lua.LBool(fn.(func(float64) bool)(n)))
+		return 1, true
+	})
+	RegisterFastPath(func(float64) float64 { return 0 }, func(L *lua.LState, fn interface{}) (int, bool) {
+		n, ok := fastNumber(L, 1)
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		L.Push(lua.LNumber(fn.(func(float64) float64)(n)))
+		return 1, true
+	})
+	RegisterFastPath(func(float64, float64) bool { return false }, func(L *lua.LState, fn interface{}) (int, bool) {
+		a, okA := fastNumber(L, 1)
+		b, okB := fastNumber(L, 2)
+		if !okA || !okB || L.GetTop() != 2 {
+			return 0, false
+		}
+		L.Push(lua.LBool(fn.(func(float64, float64) bool)(a, b)))
+		return 1, true
+	})
+	RegisterFastPath(func(float64, float64) float64 { return 0 }, func(L *lua.LState, fn interface{}) (int, bool) {
+		a, okA := fastNumber(L, 1)
+		b, okB := fastNumber(L, 2)
+		if !okA || !okB || L.GetTop() != 2 {
+			return 0, false
+		}
+		L.Push(lua.LNumber(fn.(func(float64, float64) float64)(a, b)))
+		return 1, true
+	})
+	RegisterFastPath(func(int) int { return 0 }, func(L *lua.LState, fn interface{}) (int, bool) {
+		n, ok := fastInt(L, 1)
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		L.Push(lua.LNumber(fn.(func(int) int)(n)))
+		return 1, true
+	})
+	RegisterFastPath(func(int, int) int { return 0 }, func(L *lua.LState, fn interface{}) (int, bool) {
+		a, okA := fastInt(L, 1)
+		b, okB := fastInt(L, 2)
+		if !okA || !okB || L.GetTop() != 2 {
+			return 0, false
+		}
+		L.Push(lua.LNumber(fn.(func(int, int) int)(a, b)))
+		return 1, true
+	})
+	RegisterFastPath(func(bool) bool { return false }, func(L *lua.LState, fn interface{}) (int, bool) {
+		b, ok := fastBool(L, 1)
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		L.Push(lua.LBool(fn.(func(bool) bool)(b)))
+		return 1, true
+	})
+}
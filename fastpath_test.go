@@ -0,0 +1,108 @@
+package luar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func isFastFunc(lv lua.LValue) bool {
+	fn, ok := lv.(*lua.LFunction)
+	return ok && reflect.ValueOf(fn.GFunction).Pointer() == reflect.ValueOf(funcFast).Pointer()
+}
+
+func Test_fastpath(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.SetGlobal("prefix", New(L, func(s string) bool {
+		return strings.HasPrefix(s, "CL")
+	}))
+	L.SetGlobal("add", New(L, func(a, b float64) float64 {
+		return a + b
+	}))
+	L.SetGlobal("double", New(L, func(n int) int {
+		return n * 2
+	}))
+	L.SetGlobal("upper", New(L, strings.ToUpper))
+	L.SetGlobal("fail", New(L, func(s string) string {
+		panic("cannot " + s)
+	}))
+
+	for _, name := range []string{"prefix", "add", "double", "upper", "fail"} {
+		if !isFastFunc(L.GetGlobal(name)) {
+			t.Fatalf("expecting %s to use a fast path", name)
+		}
+	}
+
+	testReturn(t, L, `return prefix("CL12"), prefix("MB12")`, "true", "false")
+	testReturn(t, L, `return add(1.5, 2), double(2.7), upper("claim")`, "3.5", "4", "CLAIM")
+
+	// Arguments that the fast path does not handle use the regular path.
+	testReturn(t, L, `return upper(12), upper(true)`, "12", "TRUE")
+	testError(t, L, `return add(1)`, "invalid number of function arguments (2 expected, got 1)")
+	testError(t, L, `return prefix({})`, "invalid type received for arg 1")
+
+	testReturn(t, L, `local ok, err = pcall(fail, "close"); return ok, string.find(tostring(err), "cannot close", 1, true) ~= nil`, "false", "true")
+}
+
+func Test_fastpath_middleware(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.SetGlobal("add", New(L, func(a, b float64) float64 {
+		return a + b
+	}))
+
+	var names []string
+	GetConfig(L).Use(func(next CallHandler) CallHandler {
+		return func(call *CallInfo) error {
+			names = append(names, call.Name)
+			return next(call)
+		}
+	})
+
+	testReturn(t, L, `return add(1, 2)`, "3")
+	if len(names) != 1 {
+		t.Fatalf("expecting the middleware to be called once, got %v", names)
+	}
+}
+
+type FastPathTestClaim struct {
+	ID string
+}
+
+func Test_fastpath_register(t *testing.T) {
+	calls := 0
+	RegisterFastPath(func(*FastPathTestClaim) string { return "" }, func(L *lua.LState, fn interface{}) (int, bool) {
+		claim, ok := unwrapAs(L.Get(1), reflect.TypeOf(&FastPathTestClaim{}))
+		if !ok || L.GetTop() != 1 {
+			return 0, false
+		}
+		calls++
+		L.Push(lua.LString(fn.(func(*FastPathTestClaim) string)(claim.Interface().(*FastPathTestClaim))))
+		return 1, true
+	})
+
+	L := lua.NewState()
+	defer L.Close()
+
+	L.SetGlobal("id", New(L, func(c *FastPathTestClaim) string {
+		return c.ID
+	}))
+	L.SetGlobal("claim", New(L, &FastPathTestClaim{ID: "CL1"}))
+
+	testReturn(t, L, `return id(claim), id({ID = "CL2"})`, "CL1", "CL2")
+	if calls != 1 {
+		t.Fatalf("expecting the fast path to be used once, got %d", calls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting RegisterFastPath to panic for non-functions")
+		}
+	}()
+	RegisterFastPath(0, nil)
+}
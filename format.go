@@ -0,0 +1,297 @@
+package luar
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/gopher-lua"
+)
+
+const (
+	// summaryItems is the maximum number of fields or elements that are shown
+	// by the default tostring.
+	summaryItems = 4
+	// dumpDepth is the default depth of dump.
+	dumpDepth = 5
+)
+
+// formatter formats Go values for tostring and dump. Pointers are shown as
+// the value they point to. Fields that Lua code may not read and values of
+// types that are not exposed are not shown.
+type formatter struct {
+	L         *lua.LState
+	config    *Config
+	multiline bool
+	b         strings.Builder
+	visiting  map[formatVisit]bool
+}
+
+// formatVisit identifies a pointer or map that is being formatted, in order to
+// detect cycles.
+type formatVisit struct {
+	Ptr  uintptr
+	Type reflect.Type
+}
+
+// formatItem is a field or element of a struct, array, slice or map.
+type formatItem struct {
+	Key   string
+	Value reflect.Value
+}
+
+func newFormatter(L *lua.LState, multiline bool) *formatter {
+	return &formatter{
+		L:         L,
+		config:    GetConfig(L),
+		multiline: multiline,
+		visiting:  make(map[formatVisit]bool),
+	}
+}
+
+// summary returns the type and a short summary of the fields or elements of
+// value, e.g. `Claim{ID=12, Amount=40.5, ...}`.
+func summary(L *lua.LState, value interface{}) string {
+	f := newFormatter(L, false)
+	f.value(reflect.ValueOf(value), 1, 0, true)
+	return f.b.String()
+}
+
+// dump returns a multi-line representation of value, which shows nested
+// values up to the given depth.
+func dump(L *lua.LState, value interface{}, depth int) string {
+	f := newFormatter(L, true)
+	f.value(reflect.ValueOf(value), depth, 0, true)
+	return f.b.String()
+}
+
+func (f *formatter) value(v reflect.Value, depth, indent int, top bool) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			f.b.WriteString("nil")
+			return
+		}
+		if !top && f.writeStringer(v) {
+			return
+		}
+		if v.Kind() == reflect.Ptr {
+			visit := formatVisit{Ptr: v.Pointer(), Type: v.Type()}
+			if f.visiting[visit] {
+				f.b.WriteString("<cycle " + formatTypeName(v.Type().Elem()) + ">")
+				return
+			}
+			f.visiting[visit] = true
+			defer delete(f.visiting, visit)
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		f.b.WriteString("nil")
+		return
+	}
+	if !top && f.writeStringer(v) {
+		return
+	}
+
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.String:
+		f.b.WriteString(strconv.Quote(v.String()))
+	case reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			f.b.WriteString("nil")
+			return
+		}
+		if t.Kind() == reflect.Map {
+			visit := formatVisit{Ptr: v.Pointer(), Type: t}
+			if f.visiting[visit] {
+				f.b.WriteString("<cycle " + formatTypeName(t) + ">")
+				return
+			}
+			f.visiting[visit] = true
+			defer delete(f.visiting, visit)
+		}
+		f.composite(v, depth, indent)
+	case reflect.Array, reflect.Struct:
+		f.composite(v, depth, indent)
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		f.b.WriteString(formatTypeName(t))
+	default:
+		f.b.WriteString(fmt.Sprint(v))
+	}
+}
+
+// writeStringer writes v using its String or Error method, if it has one.
+func (f *formatter) writeStringer(v reflect.Value) bool {
+	if !v.CanInterface() {
+		return false
+	}
+	switch value := v.Interface().(type) {
+	case error:
+		f.b.WriteString(strconv.Quote(value.Error()))
+	case fmt.Stringer:
+		f.b.WriteString(strconv.Quote(value.String()))
+	default:
+		return false
+	}
+	return true
+}
+
+func (f *formatter) composite(v reflect.Value, depth, indent int) {
+	t := v.Type()
+	f.b.WriteString(formatTypeName(t))
+	if !f.config.isExposed(t) {
+		f.b.WriteString("{<opaque>}")
+		return
+	}
+	if depth <= 0 || (t.Kind() != reflect.Struct && accessError(f.L, t, "", AccessRead) != nil) {
+		f.b.WriteString("{...}")
+		return
+	}
+
+	limit := -1
+	if !f.multiline {
+		// One more item than shown tells whether to write "...".
+		limit = summaryItems + 1
+	}
+	items := f.items(v, limit)
+	f.b.WriteString("{")
+	if f.multiline {
+		for _, item := range items {
+			f.b.WriteString("\n" + strings.Repeat("  ", indent+1) + item.Key + " = ")
+			f.value(item.Value, depth-1, indent+1, false)
+			f.b.WriteString(",")
+		}
+		if len(items) > 0 {
+			f.b.WriteString("\n" + strings.Repeat("  ", indent))
+		}
+	} else {
+		for i, item := range items {
+			if i > 0 {
+				f.b.WriteString(", ")
+			}
+			if i == summaryItems {
+				f.b.WriteString("...")
+				break
+			}
+			if t.Kind() == reflect.Struct || t.Kind() == reflect.Map {
+				f.b.WriteString(item.Key + "=")
+			}
+			f.value(item.Value, depth-1, indent, false)
+		}
+	}
+	f.b.WriteString("}")
+}
+
+// items returns the fields of a struct, or the elements of an array, slice or
+// map. The fields of embedded structs are not included, since the embedded
+// struct is. If limit is not negative, at most limit items are returned; for
+// maps, these are the first ones in the order of their keys.
+func (f *formatter) items(v reflect.Value, limit int) []formatItem {
+	var items []formatItem
+	full := func() bool {
+		return limit >= 0 && len(items) >= limit
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, field := range visibleFields(f.L, v.Type()) {
+			if full() {
+				break
+			}
+			if len(field.Index) > 1 {
+				continue
+			}
+			value := v.Field(field.Index[0])
+			if !value.CanInterface() {
+				continue
+			}
+			items = append(items, formatItem{
+				Key:   field.Name,
+				Value: value,
+			})
+		}
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len() && !full(); i++ {
+			items = append(items, formatItem{
+				Key:   "[" + strconv.Itoa(i+1) + "]",
+				Value: v.Index(i),
+			})
+		}
+	case reflect.Map:
+		if limit < 0 {
+			for _, key := range v.MapKeys() {
+				items = append(items, formatItem{
+					Key:   formatMapKey(key),
+					Value: v.MapIndex(key),
+				})
+			}
+			sort.Slice(items, func(i, j int) bool {
+				return items[i].Key < items[j].Key
+			})
+			break
+		}
+
+		// Only the first limit keys are kept, in order, so that summaries of
+		// large maps neither sort nor convert all entries.
+		var keys []reflect.Value
+		iter := v.MapRange()
+		for iter.Next() {
+			name := formatMapKey(iter.Key())
+			i := sort.Search(len(items), func(i int) bool {
+				return items[i].Key >= name
+			})
+			if i >= limit {
+				continue
+			}
+			if len(items) < limit {
+				items = append(items, formatItem{})
+				keys = append(keys, reflect.Value{})
+			}
+			copy(items[i+1:], items[i:])
+			copy(keys[i+1:], keys[i:])
+			items[i] = formatItem{Key: name}
+			keys[i] = iter.Key()
+		}
+		for i, key := range keys {
+			items[i].Value = v.MapIndex(key)
+		}
+	}
+	return items
+}
+
+// formatMapKey returns the name of a map key in formatted values.
+func formatMapKey(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	return "[" + fmt.Sprint(key) + "]"
+}
+
+// formatTypeName returns the name of t without its package, or its string
+// representation for unnamed types.
+func formatTypeName(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
+
+func luaDump(L *lua.LState) int {
+	lv := L.CheckAny(1)
+	depth := L.OptInt(2, dumpDepth)
+
+	refIface := luarValue(lv)
+	ud, ok := lv.(*lua.LUserData)
+	if refIface == nil || !ok || refIface.IfaceType != nil || isOpaque(L, lv) || reflect.TypeOf(refIface.Interface) == refTypeReflectType {
+		L.Push(L.ToStringMeta(lv))
+		return 1
+	}
+	if _, isError := refIface.Interface.(error); isError && ud.Metatable == getErrorMetatable(L) {
+		L.Push(L.ToStringMeta(lv))
+		return 1
+	}
+	L.Push(lua.LString(dump(L, refIface.Interface, depth)))
+	return 1
+}
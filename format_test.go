@@ -0,0 +1,136 @@
+package luar
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type FormatTestMember struct {
+	Name  string
+	Claim *FormatTestClaim
+}
+
+type FormatTestClaim struct {
+	ID      int `luar:"id"`
+	Amount  float64
+	Codes   []string
+	Member  *FormatTestMember
+	Notes   map[string]int
+	Private string
+}
+
+func Test_format_tostring(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	claim := &FormatTestClaim{
+		ID:     12,
+		Amount: 40.5,
+		Codes:  []string{"a", "b", "c", "d", "e"},
+		Member: &FormatTestMember{Name: "Tim"},
+	}
+
+	L.SetGlobal("claim", New(L, claim))
+	L.SetGlobal("codes", New(L, claim.Codes))
+	L.SetGlobal("notes", New(L, map[string]int{"b": 2, "a": 1}))
+	L.SetGlobal("p", New(L, &StructTestPerson{Name: "Tim", Age: 30}))
+
+	testReturn(t, L, `return tostring(claim)`, `FormatTestClaim{id=12, Amount=40.5, Codes=[]string{...}, Member=FormatTestMember{...}, ...}`)
+	testReturn(t, L, `return tostring(codes)`, `[]string{"a", "b", "c", "d", ...}`)
+	testReturn(t, L, `return tostring(notes)`, `map[string]int{a=1, b=2}`)
+	testReturn(t, L, `return tostring(p)`, `Tim (30)`)
+
+	config := GetConfig(L)
+	config.AccessPolicy = func(ctx AccessContext) error {
+		if ctx.Member == "Amount" || ctx.Member == "Codes" {
+			return errors.New("access denied")
+		}
+		return nil
+	}
+	testReturn(t, L, `return tostring(claim)`, `FormatTestClaim{id=12, Member=FormatTestMember{...}, Notes=nil, Private=""}`)
+
+	config.Tostring = func(L *lua.LState, value interface{}) string {
+		return fmt.Sprintf("<%T>", value)
+	}
+	testReturn(t, L, `return tostring(claim)`, `<*luar.FormatTestClaim>`)
+}
+
+func Test_format_tostring_large(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	members := make([]*FormatTestMember, 10000)
+	notes := make(map[string]int, 10000)
+	for i := range members {
+		members[i] = &FormatTestMember{Name: fmt.Sprint(i)}
+		notes[fmt.Sprintf("n%05d", len(members)-i)] = i
+	}
+
+	reads := 0
+	GetConfig(L).AccessPolicy = func(ctx AccessContext) error {
+		if ctx.Op == AccessRead {
+			reads++
+		}
+		return nil
+	}
+	L.SetGlobal("members", New(L, members))
+	L.SetGlobal("notes", New(L, notes))
+
+	// Summaries only format the items that they show.
+	testReturn(t, L, `return tostring(members)`, `[]*luar.FormatTestMember{FormatTestMember{...}, FormatTestMember{...}, FormatTestMember{...}, FormatTestMember{...}, ...}`)
+	testReturn(t, L, `return tostring(notes)`, `map[string]int{n00001=9999, n00002=9998, n00003=9997, n00004=9996, ...}`)
+	if reads > 20 {
+		t.Fatalf("expecting the access policy to be called for the shown items only, got %d calls", reads)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		summary(L, notes)
+	})
+	// Iterating over the map copies each key; the entries are neither
+	// sorted nor converted.
+	if allocs > float64(len(notes))+100 {
+		t.Fatalf("expecting at most one allocation per key, got %v", allocs)
+	}
+}
+
+func Test_format_dump(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	L.PreloadModule("luar", Loader)
+
+	claim := &FormatTestClaim{
+		ID:     12,
+		Amount: 40.5,
+		Codes:  []string{"a"},
+		Member: &FormatTestMember{Name: "Tim"},
+		Notes:  map[string]int{"b": 2, "a": 1},
+	}
+	claim.Member.Claim = claim
+
+	L.SetGlobal("claim", New(L, claim))
+
+	testReturn(t, L, `luar = require("luar")`)
+	testReturn(t, L, `return luar.dump(claim)`, `FormatTestClaim{
+  id = 12,
+  Amount = 40.5,
+  Codes = []string{
+    [1] = "a",
+  },
+  Member = FormatTestMember{
+    Name = "Tim",
+    Claim = <cycle FormatTestClaim>,
+  },
+  Notes = map[string]int{
+    a = 1,
+    b = 2,
+  },
+  Private = "",
+}`)
+	testReturn(t, L, `return luar.dump(claim.Member, 1)`, `FormatTestMember{
+  Name = "Tim",
+  Claim = FormatTestClaim{...},
+}`)
+	testReturn(t, L, `return luar.dump(1), luar.dump("a"), luar.dump(claim.Codes, 0)`, "1", "a", "[]string{...}")
+}
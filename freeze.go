@@ -0,0 +1,107 @@
+package luar
+
+import (
+	"reflect"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// Freeze returns an immutable view of the given luar value. The returned value
+// refers to the same Go value as lv and inherits all of its other
+// ReflectOptions. Modifications made through other views remain visible.
+//
+// Values that were not created by luar (e.g. numbers, strings and tables) are
+// returned unchanged. So are methods (e.g. claim.Close), which are immutable
+// only when called on an immutable receiver.
+func Freeze(L *lua.LState, lv lua.LValue) lua.LValue {
+	switch converted := lv.(type) {
+	case *lua.LUserData:
+		refIface, ok := converted.Value.(*reflectedInterface)
+		if !ok || refIface.Options.Immutable {
+			return lv
+		}
+		opts := refIface.Options
+		opts.Immutable = true
+
+		ud := L.NewUserData()
+		ud.Value = refIface.withOptions(opts)
+		ud.Metatable = converted.Metatable
+		return ud
+	case *lua.LFunction:
+		refIface := funcReflectedInterface(converted)
+		if refIface == nil || refIface.Options.Immutable || funcMethodInfo(converted) != nil {
+			return lv
+		}
+		opts := refIface.Options
+		opts.Immutable = true
+
+		up := L.NewUserData()
+		up.Value = refIface.withOptions(opts)
+		upvalues := []lua.LValue{up}
+		for _, upvalue := range converted.Upvalues[1:] {
+			upvalues = append(upvalues, upvalue.Value())
+		}
+		return L.NewClosure(converted.GFunction, upvalues...)
+	}
+	return lv
+}
+
+// IsFrozen returns true if lv is a luar value that is reflected as immutable.
+// It returns false for methods, whose immutability depends on the receiver.
+func IsFrozen(lv lua.LValue) bool {
+	switch converted := lv.(type) {
+	case *lua.LUserData:
+		if refIface, ok := converted.Value.(*reflectedInterface); ok {
+			return refIface.Options.Immutable
+		}
+	case *lua.LFunction:
+		if refIface := funcReflectedInterface(converted); refIface != nil && funcMethodInfo(converted) == nil {
+			return refIface.Options.Immutable
+		}
+	}
+	return false
+}
+
+// funcReflectedInterface returns the wrapped Go function of a function created
+// by funcWrapper, or nil if fn is not such a function.
+func funcReflectedInterface(fn *lua.LFunction) *reflectedInterface {
+	if !fn.IsG || len(fn.Upvalues) == 0 {
+		return nil
+	}
+	up, ok := fn.Upvalues[0].Value().(*lua.LUserData)
+	if !ok {
+		return nil
+	}
+	refIface, ok := up.Value.(*reflectedInterface)
+	if !ok {
+		return nil
+	}
+	if _, ok := refIface.Interface.(reflect.Value); !ok {
+		return nil
+	}
+	return refIface
+}
+
+// funcMethodInfo returns the method wrapped by a function created by
+// methodWrapper, or nil if fn is not such a function.
+func funcMethodInfo(fn *lua.LFunction) *methodInfo {
+	if funcReflectedInterface(fn) == nil || len(fn.Upvalues) < 2 {
+		return nil
+	}
+	if ud, ok := fn.Upvalues[1].Value().(*lua.LUserData); ok {
+		if info, ok := ud.Value.(*methodInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
+
+func luaFreeze(L *lua.LState) int {
+	L.Push(Freeze(L, L.CheckAny(1)))
+	return 1
+}
+
+func luaIsFrozen(L *lua.LState) int {
+	L.Push(lua.LBool(IsFrozen(L.CheckAny(1))))
+	return 1
+}
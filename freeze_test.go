@@ -0,0 +1,56 @@
+package luar
+
+import (
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_freeze(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	L.PreloadModule("luar", Loader)
+
+	val := "hello"
+	b := &TestTransparentPtrAccessB{Str: &val}
+
+	L.SetGlobal("b", New(L, b, ReflectOptions{TransparentPointers: true}))
+
+	testReturn(t, L, `luar = require("luar"); frozen = luar.freeze(b)`)
+	testReturn(t, L, `return luar.isfrozen(b), luar.isfrozen(frozen)`, "false", "true")
+	testReturn(t, L, `return frozen.Str`, "hello")
+	testError(t, L, `frozen.Str = "world"`, "invalid operation on immutable struct")
+	testReturn(t, L, `b.Str = "world"; return frozen.Str`, "world")
+	testReturn(t, L, `return luar.isfrozen(1), luar.freeze("str")`, "false", "str")
+}
+
+func Test_freeze_func(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	getPerson := func() *StructTestPerson {
+		return &StructTestPerson{Name: "Tim"}
+	}
+
+	L.SetGlobal("getPerson", Freeze(L, New(L, getPerson)))
+
+	if !IsFrozen(L.GetGlobal("getPerson")) {
+		t.Fatal("expecting frozen function")
+	}
+	testError(t, L, `getPerson().Name = "Bob"`, "invalid operation on immutable struct")
+}
+
+func Test_freeze_method(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	L.PreloadModule("luar", Loader)
+
+	p := &StructTestPerson{Name: "Tim", Age: 30}
+	L.SetGlobal("p", New(L, p))
+
+	// Methods take their options from the receiver, so they are not frozen.
+	testReturn(t, L, `luar = require("luar"); increase = luar.freeze(p.IncreaseAge)`)
+	testReturn(t, L, `return luar.isfrozen(increase), increase == p.IncreaseAge`, "false", "true")
+	testReturn(t, L, `increase(p); return p.Age`, "31")
+	testError(t, L, `increase(luar.freeze(p))`, "cannot call mutating method IncreaseAge on immutable object")
+}
@@ -1,7 +1,10 @@
 package luar
 
 import (
+	"fmt"
 	"reflect"
+	"runtime"
+	"runtime/debug"
 
 	"github.com/yuin/gopher-lua"
 )
@@ -13,11 +16,43 @@ type LState struct {
 	*lua.LState
 }
 
+// Options returns the ReflectOptions of the receiver of a bypass method. For
+// bypass functions, the options of the first argument that is a luar value
+// are returned, or, if there is no such argument, the options that the
+// function was created with. Outside of a bypass call, the default options
+// are returned.
+func (L *LState) Options() ReflectOptions {
+	if calls := GetConfig(L.LState).bypassOptions[L.LState]; len(calls) > 0 {
+		return calls[len(calls)-1]
+	}
+	return defaultReflectOptions()
+}
+
+// pushBypassOptions records the options of a bypass call of L, which are
+// returned by LState.Options until popBypassOptions is called.
+func (c *Config) pushBypassOptions(L *lua.LState, opts ReflectOptions) {
+	if c.bypassOptions == nil {
+		c.bypassOptions = make(map[*lua.LState][]ReflectOptions)
+	}
+	c.bypassOptions[L] = append(c.bypassOptions[L], opts)
+}
+
+func (c *Config) popBypassOptions(L *lua.LState) {
+	calls := c.bypassOptions[L]
+	if len(calls) <= 1 {
+		delete(c.bypassOptions, L)
+		return
+	}
+	c.bypassOptions[L] = calls[:len(calls)-1]
+}
+
 var (
 	refTypeLStatePtr      reflect.Type
 	refTypeLuaLValueSlice reflect.Type
 	refTypeLuaLValue      reflect.Type
 	refTypeInt            reflect.Type
+	refTypeStringer       reflect.Type
+	refTypeError          reflect.Type
 )
 
 func init() {
@@ -25,6 +60,15 @@ func init() {
 	refTypeLuaLValueSlice = reflect.TypeOf([]lua.LValue{})
 	refTypeLuaLValue = reflect.TypeOf((*lua.LValue)(nil)).Elem()
 	refTypeInt = reflect.TypeOf(int(0))
+	refTypeStringer = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	refTypeError = reflect.TypeOf((*error)(nil)).Elem()
+}
+
+// methodInfo describes a Go method that has been wrapped by methodWrapper.
+type methodInfo struct {
+	Method      reflect.Method
+	PtrReceiver bool
+	Pure        bool
 }
 
 func getFunc(L *lua.LState) (ref reflect.Value, refType reflect.Type, opts ReflectOptions) {
@@ -32,11 +76,57 @@ func getFunc(L *lua.LState) (ref reflect.Value, refType reflect.Type, opts Refle
 	ref = refIface.Interface.(reflect.Value)
 	opts = refIface.Options
 	refType = ref.Type()
+	if getMethodInfo(L) != nil && L.GetTop() > 0 {
+		// Methods are shared by every value of a type, so the options come
+		// from the receiver rather than from the wrapper.
+		opts = valueOptions(L.Get(1))
+	}
 	return
 }
 
-func isPtrReceiverMethod(L *lua.LState) bool {
-	return bool(L.Get(lua.UpvalueIndex(2)).(lua.LBool))
+func checkMethodPurity(L *lua.LState, opts ReflectOptions, info *methodInfo) {
+	if info != nil && opts.Immutable && !info.Pure {
+		L.RaiseError("cannot call mutating method %s on immutable object", info.Method.Name)
+	}
+}
+
+func checkCallAccess(L *lua.LState, config *Config, refType reflect.Type, info *methodInfo) {
+	if info != nil {
+		config.checkAccess(L, refType.In(0), info.Method.Name, AccessCall)
+	} else {
+		config.checkAccess(L, refType, "", AccessCall)
+	}
+}
+
+func getMethodInfo(L *lua.LState) *methodInfo {
+	if ud, ok := L.Get(lua.UpvalueIndex(2)).(*lua.LUserData); ok {
+		return ud.Value.(*methodInfo)
+	}
+	return nil
+}
+
+// valueOptions returns the ReflectOptions of a reflected value, or the default
+// options if v was not created by luar.
+func valueOptions(v lua.LValue) ReflectOptions {
+	if ud, ok := v.(*lua.LUserData); ok {
+		if refIface, ok := ud.Value.(*reflectedInterface); ok {
+			return refIface.Options
+		}
+	}
+	return defaultReflectOptions()
+}
+
+// updateReceiver stores the value pointed to by receiver back into ud after a
+// pointer method was called on a copy of a value receiver. The options of ud
+// are kept intact.
+func updateReceiver(ud lua.LValue, receiver reflect.Value) {
+	userData := ud.(*lua.LUserData)
+	value := receiver.Elem().Interface()
+	if refIface, ok := userData.Value.(*reflectedInterface); ok {
+		userData.Value = newReflectedInterface(value, refIface.Options)
+	} else {
+		userData.Value = value
+	}
 }
 
 func funcIsBypass(t reflect.Type) bool {
@@ -50,19 +140,34 @@ func funcIsBypass(t reflect.Type) bool {
 }
 
 func funcBypass(L *lua.LState) int {
-	// Cannot pass ReflectOptions for bypass functions
-	ref, refType, _ := getFunc(L)
+	ref, refType, opts := getFunc(L)
+	config := GetConfig(L)
+	info := getMethodInfo(L)
+	checkMethodPurity(L, opts, info)
+	checkCallAccess(L, config, refType, info)
 
+	allocated := config.allocated
 	convertedPtr := false
 	var receiver reflect.Value
 	var ud lua.LValue
 
+	if info == nil {
+		for i := 1; i <= L.GetTop(); i++ {
+			if refIface := luarValue(L.Get(i)); refIface != nil {
+				opts = refIface.Options
+				break
+			}
+		}
+	}
+	config.pushBypassOptions(L, opts)
+	defer config.popBypassOptions(L)
+
 	luarState := LState{L}
 	args := make([]reflect.Value, 0, 2)
 	if refType.NumIn() == 2 {
 		receiverHint := refType.In(0)
 		ud = L.Get(1)
-		if isPtrReceiverMethod(L) {
+		if info.PtrReceiver {
 			receiver = lValueToReflect(L, ud, receiverHint, &convertedPtr)
 		} else {
 			receiver = lValueToReflect(L, ud, receiverHint, nil)
@@ -74,18 +179,27 @@ func funcBypass(L *lua.LState) int {
 		L.Remove(1)
 	}
 	args = append(args, reflect.ValueOf(&luarState))
-	ret := ref.Call(args)[0].Interface().(int)
+	results := callFunc(L, config, ref, args, allocated)
+	if len(results) != 1 || results[0].Type() != refTypeInt {
+		L.RaiseError("invalid results for bypass function")
+	}
+	ret := int(results[0].Int())
 	if convertedPtr {
-		ud.(*lua.LUserData).Value = receiver.Elem().Interface()
+		updateReceiver(ud, receiver)
 	}
 	return ret
 }
 
 func funcRegular(L *lua.LState) int {
 	ref, refType, opts := getFunc(L)
+	config := GetConfig(L)
+	info := getMethodInfo(L)
+	checkMethodPurity(L, opts, info)
+	checkCallAccess(L, config, refType, info)
 
+	plan := planConversion(refType)
 	top := L.GetTop()
-	expected := refType.NumIn()
+	expected := len(plan.In)
 	variadic := refType.IsVariadic()
 	if !variadic && top != expected {
 		L.RaiseError("invalid number of function arguments (%d expected, got %d)", expected, top)
@@ -94,20 +208,16 @@ func funcRegular(L *lua.LState) int {
 		L.RaiseError("invalid number of function arguments (%d or more expected, got %d)", expected-1, top)
 	}
 
+	allocated := config.allocated
 	convertedPtr := false
 	var receiver reflect.Value
 	var ud lua.LValue
 
 	args := make([]reflect.Value, top)
-	for i := 0; i < L.GetTop(); i++ {
-		var hint reflect.Type
-		if variadic && i >= expected-1 {
-			hint = refType.In(expected - 1).Elem()
-		} else {
-			hint = refType.In(i)
-		}
+	for i := 0; i < top; i++ {
+		hint := plan.hint(i)
 		var arg reflect.Value
-		if i == 0 && isPtrReceiverMethod(L) {
+		if i == 0 && info != nil && info.PtrReceiver {
 			ud = L.Get(1)
 			arg = lValueToReflect(L, ud, hint, &convertedPtr)
 			receiver = arg
@@ -119,10 +229,17 @@ func funcRegular(L *lua.LState) int {
 		}
 		args[i] = arg
 	}
-	ret := ref.Call(args)
+	ret := callFunc(L, config, ref, args, allocated)
 
 	if convertedPtr {
-		ud.(*lua.LUserData).Value = receiver.Elem().Interface()
+		updateReceiver(ud, receiver)
+	}
+
+	if err := returnedError(config, refType, ret); err != nil {
+		Raise(L, err)
+	}
+	if n := len(ret); n > 0 && n == refType.NumOut() && refType.Out(n-1) == refTypeError && config.settings().RaiseReturnedErrors {
+		ret = ret[:n-1]
 	}
 
 	if len(ret) == 1 && ret[0].Type() == refTypeLuaLValueSlice {
@@ -138,12 +255,152 @@ func funcRegular(L *lua.LState) int {
 	return len(ret)
 }
 
-func funcWrapper(L *lua.LState, fn reflect.Value, isPtrReceiverMethod bool, opts ReflectOptions) *lua.LFunction {
+// returnedError returns the error returned by a function of type refType
+// with the given results if it is non-nil and config.RaiseReturnedErrors is
+// set, or nil.
+func returnedError(config *Config, refType reflect.Type, ret []reflect.Value) error {
+	n := len(ret)
+	if n == 0 || n != refType.NumOut() || refType.Out(n-1) != refTypeError || !config.settings().RaiseReturnedErrors {
+		return nil
+	}
+	err, _ := ret[n-1].Interface().(error)
+	return err
+}
+
+// callFunc calls ref with the given arguments through the middlewares of
+// config, the configuration of L. Errors returned by the middlewares, or a
+// *PanicError if ref panics, are raised as Lua errors.
+//
+// allocated is the value of Config.AllocatedBytes before the arguments were
+// converted.
+func callFunc(L *lua.LState, config *Config, ref reflect.Value, args []reflect.Value, allocated int64) []reflect.Value {
+	if config.handler == nil {
+		ret, err := callFuncRecover(L, ref, args)
+		if err != nil {
+			raiseCallError(L, err)
+		}
+		return ret
+	}
+
+	// args is copied so that it does not escape when there is no
+	// middleware.
+	call := &CallInfo{
+		L:         L,
+		Name:      funcName(L, ref),
+		Func:      ref,
+		Args:      append([]reflect.Value(nil), args...),
+		allocated: allocated,
+	}
+	if info := getMethodInfo(L); info != nil {
+		call.Receiver = info.Method.Type.In(0)
+	}
+	if err := config.handler(call); err != nil {
+		raiseCallError(L, err)
+	}
+	checkResults(L, call)
+	return call.Results
+}
+
+// checkResults raises a Lua error if the results of call, which middlewares
+// may have set or replaced, do not match the results of the called function.
+func checkResults(L *lua.LState, call *CallInfo) {
+	refType := call.Func.Type()
+	if len(call.Results) != refType.NumOut() {
+		L.RaiseError("invalid number of results for %s (%d expected, got %d)", call.Name, refType.NumOut(), len(call.Results))
+	}
+	for i, result := range call.Results {
+		if !result.IsValid() {
+			L.RaiseError("invalid result %d for %s (expected %s)", i+1, call.Name, refType.Out(i))
+		}
+		if !result.Type().AssignableTo(refType.Out(i)) {
+			L.RaiseError("invalid result %d for %s (expected %s, got %s)", i+1, call.Name, refType.Out(i), result.Type())
+		}
+	}
+}
+
+// callFuncRecover calls ref with the given arguments. If ref panics, a
+// *PanicError is returned.
+func callFuncRecover(L *lua.LState, ref reflect.Value, args []reflect.Value) (ret []reflect.Value, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			ret, err = nil, recoveredPanic(L, ref, rec)
+		}
+	}()
+	return ref.Call(args), nil
+}
+
+// recoveredPanic returns a *PanicError for the value recovered from a panic
+// of ref.
+func recoveredPanic(L *lua.LState, ref reflect.Value, rec interface{}) *PanicError {
+	// Lua errors raised by bypass functions, or by Lua functions that were
+	// called from Go, are propagated unchanged.
+	if _, ok := rec.(*lua.ApiError); ok {
+		panic(rec)
+	}
+
+	settings := GetConfig(L).settings()
+	panicErr := &PanicError{
+		Value: rec,
+		Func:  funcName(L, ref),
+	}
+	if settings.PanicStack {
+		panicErr.Stack = debug.Stack()
+	}
+	if settings.PanicHandler != nil {
+		settings.PanicHandler(L, panicErr)
+	}
+	return panicErr
+}
+
+// funcName returns the name of the called Go function.
+func funcName(L *lua.LState, ref reflect.Value) string {
+	if info := getMethodInfo(L); info != nil {
+		if receiver := info.Method.Type.In(0); receiver.Kind() == reflect.Interface {
+			return receiver.String() + "." + info.Method.Name
+		}
+	}
+	if fn := runtime.FuncForPC(ref.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ref.Type().String()
+}
+
+func funcWrapper(L *lua.LState, fn reflect.Value, opts ReflectOptions) *lua.LFunction {
+	return newFuncClosure(L, fn, opts, lua.LNil, getFastPath(fn.Type()))
+}
+
+// methodWrapper wraps a method expression (i.e. a function whose first
+// argument is the receiver). The returned function inherits the
+// ReflectOptions of the receiver it is called with.
+func methodWrapper(L *lua.LState, method reflect.Method, isPtrReceiverMethod, pure bool) *lua.LFunction {
+	info := L.NewUserData()
+	info.Value = &methodInfo{
+		Method:      method,
+		PtrReceiver: isPtrReceiverMethod,
+		Pure:        pure,
+	}
+	return newFuncClosure(L, method.Func, defaultReflectOptions(), info, GetConfig(L).staticMethod(method))
+}
+
+// newFuncClosure wraps fn. If path is not nil, fn is called through path when
+// possible (see funcFast).
+func newFuncClosure(L *lua.LState, fn reflect.Value, opts ReflectOptions, info lua.LValue, path FastPath) *lua.LFunction {
 	up := L.NewUserData()
-	up.Value = &reflectedInterface{fn, opts}
+	up.Value = newReflectedInterface(fn, opts)
 
-	if funcIsBypass(fn.Type()) {
-		return L.NewClosure(funcBypass, up, lua.LBool(isPtrReceiverMethod))
+	if planConversion(fn.Type()).Bypass {
+		return L.NewClosure(funcBypass, up, info)
+	}
+	config := GetConfig(L)
+	if path != nil && fn.CanInterface() && !config.disableFastPaths {
+		fast := L.NewUserData()
+		fast.Value = &fastFunc{
+			config: config,
+			ref:    fn,
+			fn:     fn.Interface(),
+			path:   path,
+		}
+		return L.NewClosure(funcFast, up, info, fast)
 	}
-	return L.NewClosure(funcRegular, up, lua.LBool(isPtrReceiverMethod))
+	return L.NewClosure(funcRegular, up, info)
 }
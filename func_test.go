@@ -288,3 +288,37 @@ func Test_func_transparentptr_result(t *testing.T) {
 		"hello",
 	)
 }
+
+func Test_func_bypass_options(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	// LState can be created with an unkeyed literal.
+	if opts := (&LState{L}).Options(); opts.Immutable {
+		t.Fatal("expecting default options outside of bypass calls")
+	}
+
+	// The options of nested bypass calls do not leak into the outer call.
+	options := func(L *LState) int {
+		before := L.Options().Immutable
+		if fn, ok := L.Get(2).(*lua.LFunction); ok {
+			L.Push(fn)
+			L.Call(0, 1)
+		} else {
+			L.Push(lua.LNil)
+		}
+		L.Push(lua.LBool(before))
+		L.Push(lua.LBool(L.Options().Immutable))
+		return 3
+	}
+	p := &StructTestPerson{Name: "Tim"}
+	L.SetGlobal("p", New(L, p))
+	L.SetGlobal("frozen", New(L, p, ReflectOptions{Immutable: true}))
+	L.SetGlobal("options", New(L, options))
+
+	testReturn(t, L, `return options(frozen, function() return select(2, options(p)) end)`, "false", "true", "true")
+	testReturn(t, L, `return select(2, pcall(options, p, function() error("fail") end)), select(3, options(frozen))`, "<string>:1: fail", "true")
+	if n := len(GetConfig(L).bypassOptions); n != 0 {
+		t.Fatalf("expecting no bypass calls in progress, got %d", n)
+	}
+}
@@ -0,0 +1,85 @@
+//go:build go1.18
+// +build go1.18
+
+package luar
+
+import (
+	"reflect"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// NewInterface is like NewAs, but takes the interface type as a type
+// parameter.
+//
+// Example:
+//  L.SetGlobal("claims", NewInterface[ClaimReader](L, store))
+func NewInterface[I any](L *lua.LState, value I, opts ...ReflectOptions) lua.LValue {
+	return NewAs(L, value, reflect.TypeOf((*I)(nil)).Elem(), opts...)
+}
+
+// Bind is like Config.BindType, but takes the type as a type parameter.
+//
+// Example:
+//  Bind[vendor.Claim](GetConfig(L)).
+//    Field("InternalID").Hide().
+//    Field("Amt").As("amount").ReadOnly()
+func Bind[T any](c *Config) *Binding {
+	return c.BindType(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// As returns the Go value of a value that was created by luar as a T. Values
+// are converted to pointers and pointers to values as needed, and values of
+// named types are converted to T if they have the same underlying type. false
+// is returned if lv was not created by luar or cannot be converted.
+//
+// Example:
+//  func audit(L *luar.LState) int {
+//    claim, ok := luar.As[*Claim](L.Get(1))
+//    if !ok {
+//      L.ArgError(1, "expecting Claim")
+//    }
+//    ...
+//  }
+func As[T any](lv lua.LValue) (T, bool) {
+	var zero T
+	val, ok := unwrapAs(lv, reflect.TypeOf((*T)(nil)).Elem())
+	if !ok {
+		return zero, false
+	}
+	return val.Interface().(T), true
+}
+
+// Check converts the argument at index idx to a T, using the same rules as
+// the arguments of regular functions (e.g. tables are converted to slices,
+// maps and structs). If the argument cannot be converted, an argument error
+// is raised.
+//
+// Example:
+//  func total(L *luar.LState) int {
+//    claims := luar.Check[[]*Claim](L.LState, 1)
+//    limit := luar.Opt(L.LState, 2, 100.0)
+//    ...
+//    luar.Push(L.LState, result, L.Options())
+//    return 1
+//  }
+func Check[T any](L *lua.LState, idx int) T {
+	hint := reflect.TypeOf((*T)(nil)).Elem()
+	val := lValueToReflect(L, L.Get(idx), hint, nil)
+	if !val.IsValid() {
+		L.ArgError(idx, "expecting "+hint.String())
+	}
+	// The assertion fails for nil interfaces, in which case the zero value
+	// is returned.
+	result, _ := val.Interface().(T)
+	return result
+}
+
+// Opt is like Check, but returns def if the argument at index idx is nil or
+// absent.
+func Opt[T any](L *lua.LState, idx int, def T) T {
+	if L.Get(idx) == lua.LNil {
+		return def
+	}
+	return Check[T](L, idx)
+}
@@ -0,0 +1,128 @@
+//go:build go1.18
+// +build go1.18
+
+package luar
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_iface_generic(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	store := &TestIfaceStore{
+		values: map[string]string{"a": "1"},
+	}
+
+	L.SetGlobal("r", NewInterface[TestIfaceReader](L, store))
+
+	testReturn(t, L, `return r:Get("a"), r.Set`, "1", "nil")
+}
+
+type GenericTestPerson StructTestPerson
+
+func Test_as(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	p := &StructTestPerson{Name: "Tim"}
+	lp := New(L, p)
+	lv := New(L, StructTestPerson{Name: "John"})
+
+	if v, ok := As[*StructTestPerson](lp); !ok || v != p {
+		t.Fatalf("unexpected As result %v %v", v, ok)
+	}
+	if v, ok := As[StructTestPerson](lp); !ok || v.Name != "Tim" {
+		t.Fatalf("unexpected As result %v %v", v, ok)
+	}
+	if v, ok := As[*StructTestPerson](lv); !ok || v.Name != "John" {
+		t.Fatalf("unexpected As result %v %v", v, ok)
+	}
+	if v, ok := As[fmt.Stringer](lp); !ok || v.String() != "Tim (0)" {
+		t.Fatalf("unexpected As result %v %v", v, ok)
+	}
+	if v, ok := As[GenericTestPerson](lv); !ok || v.Name != "John" {
+		t.Fatalf("unexpected As result %v %v", v, ok)
+	}
+	if _, ok := As[*TestIfaceStore](lp); ok {
+		t.Fatal("expecting As to fail for a different type")
+	}
+	if _, ok := As[int](lua.LNumber(1)); ok {
+		t.Fatal("expecting As to fail for a non-luar value")
+	}
+	if _, ok := As[StructTestPerson](New(L, (*StructTestPerson)(nil))); ok {
+		t.Fatal("expecting As to fail for a nil pointer")
+	}
+}
+
+type GenericTestClaim struct {
+	ID     int
+	Amount float64
+}
+
+func (c GenericTestClaim) Options(L *LState) int {
+	L.Push(lua.LBool(L.Options().Immutable))
+	return 1
+}
+
+func Test_check(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	claim := &GenericTestClaim{ID: 12, Amount: 10}
+
+	total := func(L *LState) int {
+		claims := Check[[]*GenericTestClaim](L.LState, 1)
+		factor := Opt(L.LState, 2, 1.0)
+		var sum float64
+		for _, c := range claims {
+			sum += c.Amount * factor
+		}
+		Push(L.LState, sum)
+		return 1
+	}
+	first := func(L *LState) int {
+		c := Check[*GenericTestClaim](L.LState, 1)
+		Push(L.LState, c, L.Options())
+		return 1
+	}
+	options := func(L *LState) int {
+		L.Push(lua.LBool(L.Options().Immutable))
+		return 1
+	}
+
+	L.SetGlobal("claim", New(L, claim))
+	L.SetGlobal("frozen", New(L, claim, ReflectOptions{Immutable: true}))
+	L.SetGlobal("total", New(L, total))
+	L.SetGlobal("first", New(L, first))
+	L.SetGlobal("options", New(L, options))
+	L.SetGlobal("frozenOptions", New(L, options, ReflectOptions{Immutable: true}))
+
+	testReturn(t, L, `return total({claim, claim}), total({claim, {Amount = 5}}, 2)`, "20", "30")
+	testError(t, L, `total("a")`, "bad argument #1 to total (expecting []*luar.GenericTestClaim)")
+	testError(t, L, `total({claim}, "x")`, "bad argument #2 to total (expecting float64)")
+
+	testReturn(t, L, `local c = first(frozen); return c.ID, (pcall(function() c.ID = 1 end))`, "12", "false")
+	testReturn(t, L, `return first(claim) == claim`, "true")
+	testReturn(t, L, `return options(1, frozen), options(claim, frozen), options(), frozenOptions()`, "true", "false", "false", "true")
+	L.SetGlobal("frozenValue", New(L, *claim, ReflectOptions{Immutable: true}))
+	testReturn(t, L, `return claim:Options(), frozenValue:Options()`, "false", "true")
+}
+
+func Test_bind(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	Bind[BindingTestClaim](GetConfig(L)).
+		Field("Amt").As("amount").ReadOnly().
+		Method("Close").As("finish")
+
+	L.SetGlobal("c", New(L, &BindingTestClaim{Amt: 10}))
+
+	testReturn(t, L, `c:finish(); return c.amount, c.Status`, "10", "closed")
+	testError(t, L, `c.amount = 5`, "cannot set read-only field amount")
+}
@@ -0,0 +1,33 @@
+package luar
+
+import (
+	"reflect"
+)
+
+// identityKey identifies a reflection of a Go pointer, map or channel in the
+// identity cache (see Config.IdentityCache).
+type identityKey struct {
+	Ptr       uintptr
+	Type      reflect.Type
+	IfaceType reflect.Type
+	Options   ReflectOptions
+}
+
+// identityKey returns the identity cache key of val. false is returned if the
+// identity cache is disabled or val has no identity.
+func (c *Config) identityKey(val reflect.Value, ifaceType reflect.Type, opts ReflectOptions) (identityKey, bool) {
+	if !c.settings().IdentityCache || !identityCacheSupported {
+		return identityKey{}, false
+	}
+	switch val.Kind() {
+	case reflect.Chan, reflect.Map, reflect.Ptr:
+	default:
+		return identityKey{}, false
+	}
+	return identityKey{
+		Ptr:       val.Pointer(),
+		Type:      val.Type(),
+		IfaceType: ifaceType,
+		Options:   opts,
+	}, true
+}
@@ -0,0 +1,25 @@
+//go:build !go1.24
+// +build !go1.24
+
+package luar
+
+import (
+	"github.com/yuin/gopher-lua"
+)
+
+// The identity cache requires weak references, which are only available
+// since Go 1.24. Config.IdentityCache has no effect on older versions.
+const identityCacheSupported = false
+
+type identityCache struct{}
+
+func (c *identityCache) get(key identityKey) *lua.LUserData {
+	return nil
+}
+
+func (c *identityCache) put(key identityKey, ud *lua.LUserData) {
+}
+
+func (c *identityCache) len() int {
+	return 0
+}
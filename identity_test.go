@@ -0,0 +1,68 @@
+//go:build go1.24
+// +build go1.24
+
+package luar
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_identity_cache(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	GetConfig(L).IdentityCache = true
+
+	friend := &StructTestPerson{Name: "Bob"}
+	p := &StructTestPerson{Name: "Tim", Friend: friend}
+	m := map[string]int{"a": 1}
+
+	L.SetGlobal("p", New(L, p))
+	L.SetGlobal("p2", New(L, p))
+	L.SetGlobal("frozen", New(L, p, ReflectOptions{Immutable: true}))
+	L.SetGlobal("friend", New(L, friend))
+	L.SetGlobal("m", New(L, m))
+	L.SetGlobal("m2", New(L, m))
+
+	testReturn(t, L, `return rawequal(p, p2), rawequal(p.Friend, p.Friend), rawequal(p.Friend, friend)`, "true", "true", "true")
+	testReturn(t, L, `return rawequal(p, frozen), rawequal(m, m2)`, "false", "true")
+	testReturn(t, L, `local seen = {}; seen[p.Friend] = true; return seen[friend]`, "true")
+}
+
+func Test_identity_cache_disabled(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	p := &StructTestPerson{Name: "Tim"}
+
+	L.SetGlobal("p", New(L, p))
+	L.SetGlobal("p2", New(L, p))
+
+	testReturn(t, L, `return rawequal(p, p2), p == p2`, "false", "true")
+}
+
+func Test_identity_cache_collect(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	config := GetConfig(L)
+	config.IdentityCache = true
+
+	for i := 0; i < 10; i++ {
+		New(L, &StructTestPerson{Age: i})
+	}
+	if n := config.identity.len(); n != 10 {
+		t.Fatalf("expecting 10 cached userdata, got %d", n)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for config.identity.len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expecting collected userdata to be removed, %d remaining", config.identity.len())
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build go1.24
+// +build go1.24
+
+package luar
+
+import (
+	"runtime"
+	"sync"
+	"weak"
+
+	"github.com/yuin/gopher-lua"
+)
+
+const identityCacheSupported = true
+
+// identityCache maps Go pointers, maps and channels to the userdata that
+// reflect them. Userdata are referenced weakly, and their entries are removed
+// once they have been garbage collected.
+type identityCache struct {
+	mu      sync.Mutex
+	entries map[identityKey]weak.Pointer[lua.LUserData]
+}
+
+func (c *identityCache) get(key identityKey) *lua.LUserData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key].Value()
+}
+
+func (c *identityCache) put(key identityKey, ud *lua.LUserData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[identityKey]weak.Pointer[lua.LUserData])
+	}
+	c.entries[key] = weak.Make(ud)
+	runtime.AddCleanup(ud, c.remove, key)
+}
+
+// remove removes the entry of key if its userdata has been collected. The
+// entry may have been replaced by a live userdata in the meantime.
+func (c *identityCache) remove(key identityKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[key].Value() == nil {
+		delete(c.entries, key)
+	}
+}
+
+func (c *identityCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
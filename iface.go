@@ -0,0 +1,17 @@
+package luar
+
+import (
+	"github.com/yuin/gopher-lua"
+)
+
+func ifaceIndex(L *lua.LState) int {
+	ud := L.CheckUserData(1)
+	key := L.CheckString(2)
+
+	mt := userDataMetatable(L, ud)
+	if fn := mt.method(key); fn != nil {
+		L.Push(fn)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package luar
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type TestIfaceReader interface {
+	Get(key string) string
+	Keys(prefix string, limit ...int) []string
+}
+
+type TestIfaceStore struct {
+	Secret string
+	values map[string]string
+}
+
+func (s *TestIfaceStore) Get(key string) string {
+	return s.values[key]
+}
+
+func (s *TestIfaceStore) Keys(prefix string, limit ...int) []string {
+	keys := []string{prefix + "a", prefix + "b"}
+	if len(limit) > 0 && limit[0] < len(keys) {
+		keys = keys[:limit[0]]
+	}
+	return keys
+}
+
+func (s *TestIfaceStore) Set(key, value string) {
+	s.values[key] = value
+}
+
+func (s *TestIfaceStore) LuarPureMethods() []string {
+	return []string{"Get"}
+}
+
+type TestIfacePureReader interface {
+	PureMethods
+	Get(key string) string
+}
+
+type TestIfaceCoded interface {
+	error
+	Code() int
+}
+
+type TestIfaceCodeError struct {
+	code int
+}
+
+func (e *TestIfaceCodeError) Error() string {
+	return "failed"
+}
+
+func (e *TestIfaceCodeError) Code() int {
+	return e.code
+}
+
+func (e *TestIfaceCodeError) String() string {
+	return "hidden"
+}
+
+func Test_iface(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	store := &TestIfaceStore{
+		Secret: "password",
+		values: map[string]string{"a": "1"},
+	}
+	useReader := func(r TestIfaceReader) string {
+		return r.Get("a")
+	}
+	useStore := func(s *TestIfaceStore) string {
+		return s.Secret
+	}
+
+	L.SetGlobal("r", NewAs(L, store, reflect.TypeOf((*TestIfaceReader)(nil)).Elem()))
+	L.SetGlobal("useReader", New(L, useReader))
+	L.SetGlobal("useStore", New(L, useStore))
+
+	testReturn(t, L, `return r:Get("a"), r:get("a")`, "1", "1")
+	testReturn(t, L, `return #r:Keys("x"), #r:Keys("x", 1)`, "2", "1")
+	testReturn(t, L, `return r.Secret, r.Set`, "nil", "nil")
+	testError(t, L, `return (-r).Secret`, "__unm undefined")
+	testReturn(t, L, `return useReader(r)`, "1")
+	testError(t, L, `return useStore(r)`, "invalid type received for arg 1")
+}
+
+func Test_iface_puremethods(t *testing.T) {
+	// The pure methods of interface types cannot be listed, so their methods
+	// are mutating.
+	L := lua.NewState()
+	defer L.Close()
+
+	store := &TestIfaceStore{values: map[string]string{"a": "1"}}
+	ifaceType := reflect.TypeOf((*TestIfacePureReader)(nil)).Elem()
+	L.SetGlobal("r", NewAs(L, store, ifaceType))
+	L.SetGlobal("frozen", NewAs(L, store, ifaceType, ReflectOptions{Immutable: true}))
+
+	testReturn(t, L, `return r:Get("a"), frozen:LuarPureMethods()[1]`, "1", "Get")
+	testError(t, L, `return frozen:Get("a")`, "cannot call mutating method Get on immutable object")
+}
+
+func Test_iface_tostring_error(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	err := &TestIfaceCodeError{code: 3}
+	L.SetGlobal("e", NewAs(L, err, reflect.TypeOf((*TestIfaceCoded)(nil)).Elem()))
+
+	testReturn(t, L, `return tostring(e), e:Code(), e.String`, "failed", "3", "nil")
+}
@@ -0,0 +1,284 @@
+package luar
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// refTypeReflectType is the dynamic type of the values stored by NewType.
+var refTypeReflectType = reflect.TypeOf(reflect.TypeOf(0))
+
+// luarValue returns the reflected value of a luar userdata or function, or
+// nil if lv was not created by luar.
+func luarValue(lv lua.LValue) *reflectedInterface {
+	switch converted := lv.(type) {
+	case *lua.LUserData:
+		if refIface, ok := converted.Value.(*reflectedInterface); ok {
+			return refIface
+		}
+	case *lua.LFunction:
+		return funcReflectedInterface(converted)
+	}
+	return nil
+}
+
+// isOpaque returns true if lv is an opaque luar value.
+func isOpaque(L *lua.LState, lv lua.LValue) bool {
+	ud, ok := lv.(*lua.LUserData)
+	return ok && ud.Metatable == getOpaqueMetatable(L)
+}
+
+func luaTypeof(L *lua.LState) int {
+	lv := L.CheckAny(1)
+	refIface := luarValue(lv)
+	switch {
+	case refIface == nil, isOpaque(L, lv):
+		L.Push(lua.LNil)
+	case refIface.IfaceType != nil:
+		L.Push(lua.LString(refIface.IfaceType.String()))
+	default:
+		if reflect.TypeOf(refIface.Interface) == refTypeReflectType {
+			L.Push(lua.LString(refIface.Interface.(reflect.Type).String()))
+		} else if fn, ok := refIface.Interface.(reflect.Value); ok {
+			L.Push(lua.LString(fn.Type().String()))
+		} else {
+			L.Push(lua.LString(reflect.TypeOf(refIface.Interface).String()))
+		}
+	}
+	return 1
+}
+
+func luaKind(L *lua.LState) int {
+	lv := L.CheckAny(1)
+	refIface := luarValue(lv)
+	switch {
+	case refIface == nil:
+		L.Push(lua.LNil)
+	case isOpaque(L, lv):
+		L.Push(lua.LString("opaque"))
+	case refIface.IfaceType != nil:
+		L.Push(lua.LString(reflect.Interface.String()))
+	default:
+		if _, ok := lv.(*lua.LFunction); ok {
+			L.Push(lua.LString(reflect.Func.String()))
+		} else if reflect.TypeOf(refIface.Interface) == refTypeReflectType {
+			L.Push(lua.LString("type"))
+		} else {
+			L.Push(lua.LString(reflect.ValueOf(refIface.Interface).Kind().String()))
+		}
+	}
+	return 1
+}
+
+func luaIsLuar(L *lua.LState) int {
+	L.Push(lua.LBool(luarValue(L.CheckAny(1)) != nil))
+	return 1
+}
+
+func luaIsPtr(L *lua.LState) int {
+	lv := L.CheckAny(1)
+	refIface := luarValue(lv)
+	isPtr := refIface != nil && refIface.IfaceType == nil && !isOpaque(L, lv) &&
+		reflect.TypeOf(refIface.Interface).Kind() == reflect.Ptr &&
+		reflect.TypeOf(refIface.Interface) != refTypeReflectType
+	L.Push(lua.LBool(isPtr))
+	return 1
+}
+
+func luaOptions(L *lua.LState) int {
+	refIface := luarValue(L.CheckAny(1))
+	if refIface == nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+	tbl := L.CreateTable(0, 4)
+	tbl.RawSetString("immutable", lua.LBool(refIface.Options.Immutable))
+	tbl.RawSetString("transparentPointers", lua.LBool(refIface.Options.TransparentPointers))
+	tbl.RawSetString("autoPopulate", lua.LBool(refIface.Options.AutoPopulate))
+	tbl.RawSetString("opaque", lua.LBool(refIface.Options.Opaque || isOpaque(L, L.Get(1))))
+	L.Push(tbl)
+	return 1
+}
+
+// luaFields returns the names of the fields of a struct or pointer to struct,
+// in declaration order. Fields that the access policy does not allow to be
+// read are omitted.
+func luaFields(L *lua.LState) int {
+	lv := L.CheckAny(1)
+	refIface := luarValue(lv)
+	if refIface == nil || refIface.IfaceType != nil || isOpaque(L, lv) {
+		L.Push(lua.LNil)
+		return 1
+	}
+	if _, ok := lv.(*lua.LUserData); !ok {
+		L.Push(lua.LNil)
+		return 1
+	}
+	vtype := reflect.TypeOf(refIface.Interface)
+	if vtype.Kind() == reflect.Ptr && vtype != refTypeReflectType {
+		vtype = vtype.Elem()
+	}
+	if vtype.Kind() != reflect.Struct {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	fields := visibleFields(L, vtype)
+	tbl := L.CreateTable(len(fields), 0)
+	for _, field := range fields {
+		tbl.Append(lua.LString(field.Name))
+	}
+	L.Push(tbl)
+	return 1
+}
+
+// ownedName returns the first of the given names under which tbl holds value.
+// Names can be missing from tbl if they collide with the names of other
+// members (see Config.NameCollisionHandler).
+func ownedName(tbl *lua.LTable, names []string, value lua.LValue) string {
+	for _, name := range names {
+		if tbl.RawGetString(name) == value {
+			return name
+		}
+	}
+	return names[0]
+}
+
+// visibleField is a field of a struct as it is visible from Lua.
+type visibleField struct {
+	// The first name of the field returned by Config.FieldNames.
+	Name  string
+	Index []int
+}
+
+// visibleFields returns the fields of the struct type t that Lua code may
+// read, in declaration order. Fields of embedded structs are included after
+// the embedded struct.
+func visibleFields(L *lua.LState, t reflect.Type) []visibleField {
+	config := GetConfig(L)
+
+	var indexes []*lua.LUserData
+	seen := make(map[*lua.LUserData]bool)
+	fields := getMetatable(L, t).RawGetString("fields").(*lua.LTable)
+	fields.ForEach(func(_, value lua.LValue) {
+		index := value.(*lua.LUserData)
+		if !seen[index] {
+			seen[index] = true
+			indexes = append(indexes, index)
+		}
+	})
+	sort.Slice(indexes, func(i, j int) bool {
+		a, b := indexes[i].Value.([]int), indexes[j].Value.([]int)
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	desc := describeType(t)
+	visible := make([]visibleField, 0, len(indexes))
+	for _, ud := range indexes {
+		field := desc.field(ud.Value.([]int))
+		if accessError(L, t, field.Field.Name, AccessRead) != nil {
+			continue
+		}
+		visible = append(visible, visibleField{
+			Name:  ownedName(fields, config.fieldNames(t, field), ud),
+			Index: field.Index,
+		})
+	}
+	return visible
+}
+
+// luaMethods returns the sorted names of the methods that can be called on a
+// luar value. Methods that the access policy does not allow to be called, and
+// mutating methods of immutable values, are omitted.
+func luaMethods(L *lua.LState) int {
+	lv := L.CheckAny(1)
+	refIface := luarValue(lv)
+	ud, ok := lv.(*lua.LUserData)
+	if refIface == nil || !ok || isOpaque(L, lv) {
+		L.Push(lua.LNil)
+		return 1
+	}
+	mt, ok := ud.Metatable.(*lua.LTable)
+	if ok {
+		mt = userDataMetatable(L, ud).LTable
+	}
+	if !ok || mt.RawGetString("methods") == lua.LNil {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	// The methods are looked up like the __index functions of the metatables
+	// do.
+	methods := mt.RawGetString("methods").(*lua.LTable)
+	ptrMethods := mt.RawGetString("ptr_methods").(*lua.LTable)
+	t := reflect.TypeOf(refIface.Interface)
+	var tables []*lua.LTable
+	switch {
+	case refIface.IfaceType != nil:
+		tables = append(tables, methods)
+	case t.Kind() == reflect.Ptr && hasIndexMetamethod(t.Elem().Kind()):
+		tables = append(tables, ptrMethods)
+	case t.Kind() == reflect.Ptr:
+		tables = append(tables, ptrMethods, methods)
+	default:
+		tables = append(tables, methods, ptrMethods)
+	}
+
+	config := GetConfig(L)
+
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, tbl := range tables {
+		tbl.ForEach(func(key, value lua.LValue) {
+			fn, ok := value.(*lua.LFunction)
+			if !ok {
+				return
+			}
+			info := funcMethodInfo(fn)
+			if info == nil {
+				// Built-in methods, such as append for slices.
+				add(key.String())
+				return
+			}
+			receiver := info.Method.Type.In(0)
+			if refIface.Options.Immutable && !info.Pure {
+				return
+			}
+			if accessError(L, receiver, info.Method.Name, AccessCall) != nil {
+				return
+			}
+			add(ownedName(tbl, config.methodNames(receiver, describeType(receiver).method(info.Method.Name)), fn))
+		})
+	}
+	sort.Strings(names)
+
+	tbl := L.CreateTable(len(names), 0)
+	for _, name := range names {
+		tbl.Append(lua.LString(name))
+	}
+	L.Push(tbl)
+	return 1
+}
+
+// hasIndexMetamethod returns true if values of the given kind, and pointers to
+// them, are indexed by the __index function of the kind's metatable.
+func hasIndexMetamethod(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice, reflect.Struct:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package luar
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type IntrospectTestEmbedded struct {
+	Note string
+}
+
+type IntrospectTestClaim struct {
+	ID int `luar:"id"`
+	IntrospectTestEmbedded
+	Amount  float64
+	Hidden  string `luar:"-"`
+	private int
+}
+
+func (c IntrospectTestClaim) Total() float64 {
+	return c.Amount
+}
+
+func (c *IntrospectTestClaim) Adjust(amount float64) {
+	c.Amount += amount
+}
+
+func Test_introspect(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	L.PreloadModule("luar", Loader)
+
+	claim := &IntrospectTestClaim{ID: 12}
+	store := &TestIfaceStore{}
+
+	L.SetGlobal("claim", New(L, claim))
+	L.SetGlobal("value", New(L, *claim))
+	L.SetGlobal("frozen", New(L, claim, ReflectOptions{Immutable: true}))
+	L.SetGlobal("frozenValue", New(L, *claim, ReflectOptions{Immutable: true}))
+	L.SetGlobal("reader", NewAs(L, store, reflect.TypeOf((*TestIfaceReader)(nil)).Elem()))
+	L.SetGlobal("opaque", New(L, claim, ReflectOptions{Opaque: true}))
+	L.SetGlobal("Claim", NewType(L, IntrospectTestClaim{}))
+	L.SetGlobal("fn", New(L, func(int) string { return "" }))
+	L.SetGlobal("s", New(L, []int{1}))
+
+	testReturn(t, L, `luar = require("luar")`)
+	testReturn(t, L, `return luar.isluar(claim), luar.isluar(fn), luar.isluar({}), luar.isluar(1)`, "true", "true", "false", "false")
+	testReturn(t, L, `return luar.typeof(claim), luar.typeof(value), luar.typeof(reader)`, "*luar.IntrospectTestClaim", "luar.IntrospectTestClaim", "luar.TestIfaceReader")
+	testReturn(t, L, `return luar.typeof(Claim), luar.typeof(fn), luar.typeof(opaque), luar.typeof("x")`, "luar.IntrospectTestClaim", "func(int) string", "nil", "nil")
+	testReturn(t, L, `return luar.kind(claim), luar.kind(value), luar.kind(reader), luar.kind(Claim)`, "ptr", "struct", "interface", "type")
+	testReturn(t, L, `return luar.kind(fn), luar.kind(opaque), luar.kind(s), luar.kind(claim.Total)`, "func", "opaque", "slice", "func")
+	testReturn(t, L, `return luar.isptr(claim), luar.isptr(value), luar.isptr(reader), luar.isptr(Claim), luar.isptr(opaque)`, "true", "false", "false", "false", "false")
+	testReturn(t, L, `return table.concat(luar.fields(claim), ",")`, "id,IntrospectTestEmbedded,Note,Amount")
+	testReturn(t, L, `return table.concat(luar.fields(value), ","), luar.fields(reader), luar.fields(opaque), luar.fields(s)`, "id,IntrospectTestEmbedded,Note,Amount", "nil", "nil", "nil")
+	testReturn(t, L, `return table.concat(luar.methods(claim), ","), table.concat(luar.methods(value), ",")`, "Adjust,Total", "Adjust,Total")
+	testReturn(t, L, `return table.concat(luar.methods(frozen), ","), table.concat(luar.methods(frozenValue), ","), table.concat(luar.methods(reader), ",")`, "Total", "Total", "Get,Keys")
+	testReturn(t, L, `return table.concat(luar.methods(s), ","), luar.methods(opaque)`, "append,capacity", "nil")
+	testReturn(t, L, `local o = luar.options(frozen); return o.immutable, o.opaque, luar.options(opaque).opaque, luar.options(1)`, "true", "false", "true", "nil")
+}
+
+func Test_introspect_policies(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	L.PreloadModule("luar", Loader)
+
+	config := GetConfig(L)
+	config.FieldNames = func(s reflect.Type, f reflect.StructField) []string {
+		return []string{"f_" + f.Name}
+	}
+	config.MethodNames = func(t reflect.Type, m reflect.Method) []string {
+		return []string{"m_" + m.Name, m.Name}
+	}
+	config.AccessPolicy = func(ctx AccessContext) error {
+		if ctx.Member == "Amount" || ctx.Member == "Adjust" {
+			return errors.New("access denied")
+		}
+		return nil
+	}
+
+	L.SetGlobal("claim", New(L, &IntrospectTestClaim{}))
+
+	testReturn(t, L, `luar = require("luar")`)
+	testReturn(t, L, `return table.concat(luar.fields(claim), ",")`, "f_ID,f_IntrospectTestEmbedded,f_Note,f_Hidden")
+	testReturn(t, L, `return table.concat(luar.methods(claim), ",")`, "m_Total")
+}
@@ -0,0 +1,56 @@
+package luar
+
+import (
+	"math"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func (c *Config) checkSliceLength(L *lua.LState, length int) {
+	if limit := c.settings().MaxSliceLength; limit > 0 && length > limit {
+		L.RaiseError("length %d exceeds limit of %d", length, limit)
+	}
+}
+
+func (c *Config) checkMapEntries(L *lua.LState, entries int) {
+	if limit := c.settings().MaxMapEntries; limit > 0 && entries > limit {
+		L.RaiseError("map entries exceed limit of %d", limit)
+	}
+}
+
+func (c *Config) checkChanBuffer(L *lua.LState, buffer int) {
+	if limit := c.settings().MaxChanBuffer; limit > 0 && buffer > limit {
+		L.RaiseError("channel buffer %d exceeds limit of %d", buffer, limit)
+	}
+}
+
+// allocate records that luar is about to allocate count values of the given
+// size on behalf of Lua code, and raises a Lua error if this exceeds the
+// allocation budget.
+func (c *Config) allocate(L *lua.LState, count int, size uintptr) {
+	total := int64(math.MaxInt64)
+	if size == 0 || int64(count) <= math.MaxInt64/int64(size) {
+		total = int64(count) * int64(size)
+	}
+	if limit := c.settings().MaxAllocBytes; limit > 0 && total > limit-c.allocated {
+		L.RaiseError("allocation budget of %d bytes exceeded", limit)
+	}
+	if total < math.MaxInt64-c.allocated {
+		c.allocated += total
+	} else {
+		c.allocated = math.MaxInt64
+	}
+}
+
+// AllocatedBytes returns the approximate number of bytes that luar has
+// allocated on behalf of Lua code since the state was created or
+// ResetAllocatedBytes was last called.
+func (c *Config) AllocatedBytes() int64 {
+	return c.allocated
+}
+
+// ResetAllocatedBytes resets the number of allocated bytes that is checked
+// against MaxAllocBytes, e.g. before a pooled state runs another script.
+func (c *Config) ResetAllocatedBytes() {
+	c.allocated = 0
+}
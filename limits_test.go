@@ -0,0 +1,103 @@
+package luar
+
+import (
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_limits_type(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.MaxSliceLength = 100
+	config.MaxChanBuffer = 10
+
+	L.SetGlobal("Ints", NewType(L, []int{}))
+	L.SetGlobal("Buf", NewType(L, make(chan int)))
+	L.SetGlobal("Arr", NewType(L, [1000]int{}))
+
+	testReturn(t, L, `return #Ints(100)`, "100")
+	testError(t, L, `Ints(1e10)`, "length 10000000000 exceeds limit of 100")
+	testError(t, L, `Ints(0, 101)`, "length 101 exceeds limit of 100")
+	testError(t, L, `Ints(-1)`, "negative length")
+	testError(t, L, `Buf(1e10)`, "channel buffer 10000000000 exceeds limit of 10")
+	testError(t, L, `Arr()`, "length 1000 exceeds limit of 100")
+	testReturn(t, L, `return pcall(function() return Buf(1e10) end)`, "false", "<string>:1: channel buffer 10000000000 exceeds limit of 10")
+}
+
+func Test_limits_append(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.MaxSliceLength = 3
+
+	L.SetGlobal("s", New(L, []string{"a"}))
+
+	testReturn(t, L, `s = s:append("b", "c"); return #s`, "3")
+	testError(t, L, `s = s:append("d")`, "length 4 exceeds limit of 3")
+}
+
+func Test_limits_conversion(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.MaxSliceLength = 3
+	config.MaxMapEntries = 2
+
+	e := &TestSliceConversion{}
+	m := &TestMapConversion{}
+	L.SetGlobal("e", New(L, e))
+	L.SetGlobal("m", New(L, m))
+
+	testReturn(t, L, `e.S = {"a", "b", "c"}; return #e.S`, "3")
+	testError(t, L, `e.S = {"a", "b", "c", "d"}`, "length 4 exceeds limit of 3")
+	testReturn(t, L, `m.S = {a = "1", b = "2"}; return #m.S`, "2")
+	testError(t, L, `m.S = {a = "1", b = "2", c = "3"}`, "map entries exceed limit of 2")
+}
+
+func Test_limits_allocbytes(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.MaxAllocBytes = 1024
+
+	L.SetGlobal("Bytes", NewType(L, []byte{}))
+
+	testReturn(t, L, `b = Bytes(1000)`)
+	if n := config.AllocatedBytes(); n != 1000 {
+		t.Fatalf("expecting 1000 allocated bytes, got %d", n)
+	}
+	testError(t, L, `b = Bytes(100)`, "allocation budget of 1024 bytes exceeded")
+
+	config.ResetAllocatedBytes()
+	testReturn(t, L, `b = Bytes(100)`)
+}
+
+func Test_limits_map_insert(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.MaxMapEntries = 3
+
+	m := map[string]int{"a": 1}
+	L.SetGlobal("m", New(L, m))
+
+	testReturn(t, L, `m.b = 2; m.c = 3; m.a = 4; return #m`, "3")
+	testError(t, L, `m.d = 4`, "map entries exceed limit of 3")
+	testReturn(t, L, `m.a = nil; m.d = 4; return #m`, "3")
+
+	// Each new entry is charged, so growing a map is bounded by the
+	// allocation budget.
+	config.MaxMapEntries = 0
+	config.MaxAllocBytes = config.AllocatedBytes() + 1000
+	testError(t, L, `for i = 1, 1e6 do m["k" .. i] = i end`, "allocation budget of")
+	if len(m) > 1000 {
+		t.Fatalf("expecting the budget to stop the inserts, got %d entries", len(m))
+	}
+}
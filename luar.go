@@ -68,12 +68,29 @@ func New(L *lua.LState, value interface{}, opts ...ReflectOptions) lua.LValue {
 	case reflect.Float32, reflect.Float64:
 		return lua.LNumber(val.Float())
 	case reflect.Array, reflect.Chan, reflect.Map, reflect.Ptr, reflect.Slice, reflect.Struct:
+		config := GetConfig(L)
+		if !reflectOptions.Opaque && !config.isExposed(val.Type()) {
+			reflectOptions.Opaque = true
+		}
+		key, cached := config.identityKey(val, nil, reflectOptions)
+		if cached {
+			if ud := config.identity.get(key); ud != nil {
+				return ud
+			}
+		}
 		ud := L.NewUserData()
 		ud.Value = newReflectedInterface(val.Interface(), reflectOptions)
-		ud.Metatable = getMetatableFromValue(L, val)
+		if reflectOptions.Opaque {
+			ud.Metatable = getOpaqueMetatable(L)
+		} else {
+			ud.Metatable = getMetatableFromValue(L, val)
+		}
+		if cached {
+			config.identity.put(key, ud)
+		}
 		return ud
 	case reflect.Func:
-		return funcWrapper(L, val, false, reflectOptions)
+		return funcWrapper(L, val, reflectOptions)
 	case reflect.String:
 		return lua.LString(val.String())
 	default:
@@ -83,6 +100,116 @@ func New(L *lua.LState, value interface{}, opts ...ReflectOptions) lua.LValue {
 	}
 }
 
+// basicLValue returns the Lua value of v, like New, if v is a boolean, number
+// or string. It avoids converting v to an interface.
+func basicLValue(v reflect.Value) (lua.LValue, bool) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return lua.LBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return lua.LNumber(float64(v.Int())), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return lua.LNumber(float64(v.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		return lua.LNumber(v.Float()), true
+	case reflect.String:
+		return lua.LString(v.String()), true
+	}
+	return nil, false
+}
+
+// setBasic sets v, which must be settable, to lv, like lValueToReflect, if v
+// is a boolean, number or string and lv is a Lua value of the same kind. false
+// is returned otherwise.
+func setBasic(v reflect.Value, lv lua.LValue) bool {
+	switch converted := lv.(type) {
+	case lua.LBool:
+		if v.Kind() == reflect.Bool {
+			v.SetBool(bool(converted))
+			return true
+		}
+	case lua.LNumber:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(int64(converted))
+			return true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(uint64(converted))
+			return true
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(converted))
+			return true
+		}
+	case lua.LString:
+		if v.Kind() == reflect.String {
+			v.SetString(string(converted))
+			return true
+		}
+	}
+	return false
+}
+
+// NewAs creates and returns a new lua.LValue for the given value, exposed as
+// the given interface type. Only the methods declared on ifaceType can be
+// called from Lua, and the value can only be converted back to ifaceType (or
+// to an interface type that ifaceType implements) when passed to Go.
+//
+// NewAs panics if ifaceType is not an interface type or if value does not
+// implement it.
+func NewAs(L *lua.LState, value interface{}, ifaceType reflect.Type, opts ...ReflectOptions) lua.LValue {
+	reflectOptions := defaultReflectOptions()
+	if len(opts) > 0 {
+		reflectOptions = opts[0]
+	}
+
+	if ifaceType.Kind() != reflect.Interface {
+		panic("luar: NewAs called with non-interface type " + ifaceType.String())
+	}
+	if value == nil {
+		return lua.LNil
+	}
+	val := reflect.ValueOf(value)
+	if !val.Type().Implements(ifaceType) {
+		panic("luar: " + val.Type().String() + " does not implement " + ifaceType.String())
+	}
+	switch val.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		if val.IsNil() {
+			return lua.LNil
+		}
+	}
+
+	config := GetConfig(L)
+	if !reflectOptions.Opaque && !config.isExposed(ifaceType) {
+		reflectOptions.Opaque = true
+	}
+	key, cached := config.identityKey(val, ifaceType, reflectOptions)
+	if cached {
+		if ud := config.identity.get(key); ud != nil {
+			return ud
+		}
+	}
+	ud := L.NewUserData()
+	refIface := newReflectedInterface(value, reflectOptions)
+	refIface.IfaceType = ifaceType
+	ud.Value = refIface
+	if reflectOptions.Opaque {
+		ud.Metatable = getOpaqueMetatable(L)
+	} else {
+		ud.Metatable = getMetatable(L, ifaceType)
+	}
+	if cached {
+		config.identity.put(key, ud)
+	}
+	return ud
+}
+
+// Push converts value to a lua.LValue using New and pushes it onto the stack
+// of L.
+func Push(L *lua.LState, value interface{}, opts ...ReflectOptions) {
+	L.Push(New(L, value, opts...))
+}
+
 // ToReflect converts the lua.LValue to a reflect.Value.
 //
 // Whenever possible, this will be a strongly-typed Go object matching an object that
@@ -98,6 +225,57 @@ func ToReflect(L *lua.LState, value lua.LValue, hint reflect.Type) (reflect.Valu
 	return reflectVal, true
 }
 
+// Unwrap returns the Go value and the ReflectOptions of a value that was
+// created by luar (e.g. using New, NewAs or NewType, or a converted function).
+// false is returned if lv was not created by luar.
+//
+// For values created by NewAs, the concrete value is returned. For types
+// created by NewType, the reflect.Type is returned.
+func Unwrap(lv lua.LValue) (interface{}, ReflectOptions, bool) {
+	refIface := luarValue(lv)
+	if refIface == nil {
+		return nil, ReflectOptions{}, false
+	}
+	if fn, ok := refIface.Interface.(reflect.Value); ok {
+		return fn.Interface(), refIface.Options, true
+	}
+	return refIface.Interface, refIface.Options, true
+}
+
+// unwrapAs converts the Go value of a luar value to the type t (see
+// convertValue).
+func unwrapAs(lv lua.LValue, t reflect.Type) (reflect.Value, bool) {
+	value, _, ok := Unwrap(lv)
+	if !ok || value == nil {
+		return reflect.Value{}, false
+	}
+	return convertValue(reflect.ValueOf(value), t)
+}
+
+// convertValue converts val to the type t. Like the receivers of methods,
+// values are converted to pointers by copying them into a new value, and
+// pointers are converted to values by dereferencing them.
+func convertValue(val reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	switch {
+	case val.Type().AssignableTo(t):
+		converted := reflect.New(t).Elem()
+		converted.Set(val)
+		return converted, true
+	case val.Kind() != reflect.Ptr && t.Kind() == reflect.Ptr && val.Type() == t.Elem():
+		converted := reflect.New(t.Elem())
+		converted.Elem().Set(val)
+		return converted, true
+	case val.Kind() == reflect.Ptr && val.Type().Elem() == t:
+		if val.IsNil() {
+			return reflect.Value{}, false
+		}
+		return val.Elem(), true
+	case val.Type().ConvertibleTo(t) && val.Kind() == t.Kind():
+		return val.Convert(t), true
+	}
+	return reflect.Value{}, false
+}
+
 // ReflectOptions is a configuration that can be used to alter the behavior of a
 // reflected gopher-luar object.
 type ReflectOptions struct {
@@ -115,6 +293,11 @@ type ReflectOptions struct {
 	// For structs, will auto-populate pointer fields with their appropriate Go
 	// type. This is only applicable if TransparentPointers is on.
 	AutoPopulate bool
+	// Controls whether the value is handed to Lua as an opaque handle. Opaque
+	// values have no accessible fields, methods or operators, but can be
+	// passed back to Go functions unchanged. Only applies to arrays,
+	// channels, maps, pointers, slices and structs.
+	Opaque bool
 }
 
 // Default options if no ReflectOptions struct is passed into luar.New().
@@ -131,6 +314,27 @@ func defaultReflectOptions() ReflectOptions {
 type reflectedInterface struct {
 	Interface interface{}
 	Options   ReflectOptions
+	// The interface type the value is exposed as, if created by NewAs.
+	IfaceType reflect.Type
+}
+
+// withOptions returns a copy of r with the given options.
+func (r *reflectedInterface) withOptions(opts ReflectOptions) *reflectedInterface {
+	refIface := *r
+	refIface.Options = opts
+	return &refIface
+}
+
+// value returns the reflected value. If the value is exposed as an interface
+// type, the returned value has that type.
+func (r *reflectedInterface) value() reflect.Value {
+	val := reflect.ValueOf(r.Interface)
+	if r.IfaceType != nil {
+		iface := reflect.New(r.IfaceType).Elem()
+		iface.Set(val)
+		return iface
+	}
+	return val
 }
 
 func newReflectedInterface(iface interface{}, opts ReflectOptions) *reflectedInterface {
@@ -140,24 +344,40 @@ func newReflectedInterface(iface interface{}, opts ReflectOptions) *reflectedInt
 // NewType returns a new type creator for the given value's type.
 //
 // When the returned lua.LValue is called, a new value will be created that is the
-// same type as value's type.
-func NewType(L *lua.LState, value interface{}) lua.LValue {
+// same type as value's type. The created values are reflected with the given
+// ReflectOptions.
+func NewType(L *lua.LState, value interface{}, opts ...ReflectOptions) lua.LValue {
+	reflectOptions := defaultReflectOptions()
+	if len(opts) > 0 {
+		reflectOptions = opts[0]
+	}
+
 	val := reflect.TypeOf(value)
 	ud := L.NewUserData()
-	ud.Value = val
+	ud.Value = newReflectedInterface(val, reflectOptions)
 	ud.Metatable = getTypeMetatable(L, val)
 
 	return ud
 }
 
+// conversionError is raised when lValueToReflect fails to convert a value. It
+// is recovered and results in an invalid reflect.Value.
+type conversionError string
+
 func lValueToReflect(L *lua.LState, v lua.LValue, hint reflect.Type, tryConvertPtr *bool) (r reflect.Value) {
 	defer func() {
-		if recover() != nil {
+		if rec := recover(); rec != nil {
+			// Lua errors, e.g. exceeded limits, are not conversion failures
+			if _, ok := rec.(*lua.ApiError); ok {
+				panic(rec)
+			}
 			r = reflect.Value{}
 		}
 	}()
 
-	if hint.Implements(refTypeLuaLValue) {
+	// Types without methods cannot implement lua.LValue, which saves the
+	// lookup of the plan for most hints.
+	if hint.NumMethod() > 0 && planConversion(hint).LValue {
 		return reflect.ValueOf(v)
 	}
 
@@ -230,7 +450,7 @@ func lValueToReflect(L *lua.LState, v lua.LValue, hint reflect.Type, tryConvertP
 		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
 			return reflect.Zero(hint)
 		default:
-			L.RaiseError("cannot convert nil to %s", hint.String())
+			panic(conversionError("cannot convert nil to " + hint.String()))
 		}
 	case *lua.LState:
 		return reflect.ValueOf(converted).Convert(hint)
@@ -241,13 +461,16 @@ func lValueToReflect(L *lua.LState, v lua.LValue, hint reflect.Type, tryConvertP
 		case hint.Kind() == reflect.Slice:
 			elemType := hint.Elem()
 			length := converted.Len()
+			config := GetConfig(L)
+			config.checkSliceLength(L, length)
+			config.allocate(L, length, elemType.Size())
 			s := reflect.MakeSlice(hint, length, length)
 
 			for i := 0; i < length; i++ {
 				value := converted.RawGetInt(i + 1)
 				elemValue := lValueToReflect(L, value, elemType, nil)
 				if !elemValue.IsValid() {
-					L.RaiseError("unable to convert value")
+					panic(conversionError("unable to convert value"))
 				}
 				s.Index(i).Set(elemValue)
 			}
@@ -257,6 +480,7 @@ func lValueToReflect(L *lua.LState, v lua.LValue, hint reflect.Type, tryConvertP
 		case hint.Kind() == reflect.Map:
 			keyType := hint.Key()
 			elemType := hint.Elem()
+			config := GetConfig(L)
 			s := reflect.MakeMap(hint)
 
 			converted.ForEach(func(key, value lua.LValue) {
@@ -266,12 +490,14 @@ func lValueToReflect(L *lua.LState, v lua.LValue, hint reflect.Type, tryConvertP
 
 				lKey := lValueToReflect(L, key, keyType, nil)
 				if !lKey.IsValid() {
-					L.RaiseError("unable to convert value")
+					panic(conversionError("unable to convert value"))
 				}
 				lValue := lValueToReflect(L, value, elemType, nil)
 				if !lValue.IsValid() {
-					L.RaiseError("unable to convert value")
+					panic(conversionError("unable to convert value"))
 				}
+				config.checkMapEntries(L, s.Len()+1)
+				config.allocate(L, 1, keyType.Size()+elemType.Size())
 				s.SetMapIndex(lKey, lValue)
 			})
 
@@ -282,6 +508,7 @@ func lValueToReflect(L *lua.LState, v lua.LValue, hint reflect.Type, tryConvertP
 			isPtr = true
 			fallthrough
 		case hint.Kind() == reflect.Struct:
+			GetConfig(L).allocate(L, 1, hint.Size())
 			s := reflect.New(hint)
 			t := s.Elem()
 
@@ -297,15 +524,15 @@ func lValueToReflect(L *lua.LState, v lua.LValue, hint reflect.Type, tryConvertP
 				fieldName := key.String()
 				index := mt.fieldIndex(fieldName)
 				if index == nil {
-					L.RaiseError("invalid field %s", fieldName)
+					panic(conversionError("invalid field " + fieldName))
 				}
-				field := hint.FieldByIndex(index)
+				field := t.FieldByIndex(index)
 
-				lValue := lValueToReflect(L, value, field.Type, nil)
+				lValue := lValueToReflect(L, value, field.Type(), nil)
 				if !lValue.IsValid() {
-					L.RaiseError("unable to convert value")
+					panic(conversionError("unable to convert value"))
 				}
-				t.FieldByIndex(field.Index).Set(lValue)
+				field.Set(lValue)
 			})
 
 			if isPtr {
@@ -320,7 +547,7 @@ func lValueToReflect(L *lua.LState, v lua.LValue, hint reflect.Type, tryConvertP
 	case *lua.LUserData:
 		var val reflect.Value
 		if refIface, ok := converted.Value.(*reflectedInterface); ok {
-			val = reflect.ValueOf(refIface.Interface)
+			val = refIface.value()
 		} else {
 			val = reflect.ValueOf(converted.Value)
 		}
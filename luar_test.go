@@ -346,3 +346,46 @@ func Test_toreflect(t *testing.T) {
 		})
 	}
 }
+
+func Test_luar_opaque(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	p := &StructTestPerson{Name: "Tim"}
+	getName := func(p *StructTestPerson) string {
+		return p.Name
+	}
+
+	L.SetGlobal("p", New(L, p, ReflectOptions{Opaque: true}))
+	L.SetGlobal("getName", New(L, getName))
+
+	testError(t, L, `return p.Name`, "attempt to index a non-table object")
+	testError(t, L, `return p:Hello()`, "attempt to index a non-table object")
+	testReturn(t, L, `return getName(p)`, "Tim")
+}
+
+func Test_unwrap(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	p := &StructTestPerson{Name: "Tim"}
+	fn := func() {}
+
+	if value, opts, ok := Unwrap(New(L, p, ReflectOptions{Immutable: true})); !ok || value != p || !opts.Immutable {
+		t.Fatalf("unexpected unwrap result %v %v %v", value, opts, ok)
+	}
+	if value, _, ok := Unwrap(New(L, fn)); !ok || reflect.ValueOf(value).Pointer() != reflect.ValueOf(fn).Pointer() {
+		t.Fatalf("unexpected unwrap result %v %v", value, ok)
+	}
+	if value, _, ok := Unwrap(NewType(L, StructTestPerson{})); !ok || value != reflect.TypeOf(StructTestPerson{}) {
+		t.Fatalf("unexpected unwrap result %v %v", value, ok)
+	}
+	if value, opts, ok := Unwrap(New(L, p, ReflectOptions{Opaque: true})); !ok || value != p || !opts.Opaque {
+		t.Fatalf("unexpected unwrap result %v %v %v", value, opts, ok)
+	}
+	for _, lv := range []lua.LValue{lua.LNumber(1), lua.LString("a"), L.NewTable(), L.NewUserData(), lua.LNil} {
+		if _, _, ok := Unwrap(lv); ok {
+			t.Fatalf("expecting %v not to unwrap", lv)
+		}
+	}
+}
@@ -22,6 +22,15 @@ func mapIndex(L *lua.LState) int {
 
 	convertedKey := lValueToReflect(L, key, ref.Type().Key(), nil)
 	if convertedKey.IsValid() {
+		// Access is checked before the lookup so that scripts that are
+		// denied access cannot tell which keys exist.
+		if err := accessError(L, ref.Type(), "", AccessRead); err != nil {
+			if fn := mapMethod(mt, key); fn != nil {
+				L.Push(fn)
+				return 1
+			}
+			raiseMessage(L, err, err.Error())
+		}
 		item := ref.MapIndex(convertedKey)
 		if item.IsValid() {
 			L.Push(New(L, item.Interface(), opts))
@@ -29,23 +38,23 @@ func mapIndex(L *lua.LState) int {
 		}
 	}
 
-	if !isPtr {
-		if lstring, ok := key.(lua.LString); ok {
-			if fn := mt.method(string(lstring)); fn != nil {
-				L.Push(fn)
-				return 1
-			}
-		}
+	if fn := mapMethod(mt, key); fn != nil {
+		L.Push(fn)
+		return 1
 	}
+	return 0
+}
 
-	if lstring, ok := key.(lua.LString); ok {
-		if fn := mt.ptrMethod(string(lstring)); fn != nil {
-			L.Push(fn)
-			return 1
-		}
+// mapMethod returns the method of a map value named key, or nil.
+func mapMethod(mt *Metatable, key lua.LValue) lua.LValue {
+	lstring, ok := key.(lua.LString)
+	if !ok {
+		return nil
 	}
-
-	return 0
+	if fn := mt.method(string(lstring)); fn != nil {
+		return fn
+	}
+	return mt.ptrMethod(string(lstring))
 }
 
 func mapNewIndex(L *lua.LState) int {
@@ -58,6 +67,7 @@ func mapNewIndex(L *lua.LState) int {
 	if opts.Immutable {
 		L.RaiseError("invalid operation on immutable map")
 	}
+	checkAccess(L, ref.Type(), "", AccessWrite)
 
 	key := L.CheckAny(2)
 	value := L.CheckAny(3)
@@ -72,6 +82,11 @@ func mapNewIndex(L *lua.LState) int {
 		if !convertedValue.IsValid() {
 			L.ArgError(3, "invalid map value")
 		}
+		if !ref.MapIndex(convertedKey).IsValid() {
+			config := GetConfig(L)
+			config.checkMapEntries(L, ref.Len()+1)
+			config.allocate(L, 1, ref.Type().Key().Size()+ref.Type().Elem().Size())
+		}
 	}
 	ref.SetMapIndex(convertedKey, convertedValue)
 	return 0
@@ -91,6 +106,7 @@ func mapCall(L *lua.LState) int {
 	if isPtr {
 		L.RaiseError("invalid operation on map pointer")
 	}
+	checkAccess(L, ref.Type(), "", AccessIterate)
 	keys := ref.MapKeys()
 	i := 0
 	fn := func(L *lua.LState) int {
@@ -110,6 +126,11 @@ func mapEq(L *lua.LState) int {
 	ref1, _, _, isPtr1 := check(L, 1, reflect.Map)
 	ref2, _, _, isPtr2 := check(L, 2, reflect.Map)
 
+	if equal, ok := customEqual(L, ref1, ref2); ok {
+		L.Push(lua.LBool(equal))
+		return 1
+	}
+
 	if isPtr1 && isPtr2 {
 		L.Push(lua.LBool(ref1.Pointer() == ref2.Pointer()))
 		return 1
@@ -2,6 +2,7 @@ package luar
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/yuin/gopher-lua"
 )
@@ -27,7 +28,7 @@ func MT(L *lua.LState, value interface{}) *Metatable {
 
 func (m *Metatable) method(name string) lua.LValue {
 	methods := m.RawGetString("methods").(*lua.LTable)
-	if fn := methods.RawGetString(name); fn != lua.LNil {
+	if fn := m.lookup(methods, name); fn != lua.LNil {
 		return fn
 	}
 	return nil
@@ -35,7 +36,7 @@ func (m *Metatable) method(name string) lua.LValue {
 
 func (m *Metatable) ptrMethod(name string) lua.LValue {
 	methods := m.RawGetString("ptr_methods").(*lua.LTable)
-	if fn := methods.RawGetString(name); fn != lua.LNil {
+	if fn := m.lookup(methods, name); fn != lua.LNil {
 		return fn
 	}
 	return nil
@@ -43,8 +44,19 @@ func (m *Metatable) ptrMethod(name string) lua.LValue {
 
 func (m *Metatable) fieldIndex(name string) []int {
 	fields := m.RawGetString("fields").(*lua.LTable)
-	if index := fields.RawGetString(name); index != lua.LNil {
+	if index := m.lookup(fields, name); index != lua.LNil {
 		return index.(*lua.LUserData).Value.([]int)
 	}
 	return nil
 }
+
+// lookup returns the member of tbl with the given name. If the metatable was
+// created with Config.CaseInsensitiveNames, the lowercase name is tried as
+// well.
+func (m *Metatable) lookup(tbl *lua.LTable, name string) lua.LValue {
+	value := tbl.RawGetString(name)
+	if value == lua.LNil && m.RawGetString("case_insensitive") == lua.LTrue {
+		value = tbl.RawGetString(strings.ToLower(name))
+	}
+	return value
+}
@@ -0,0 +1,236 @@
+package luar
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics collects statistics about the Go functions and methods that are
+// called from Lua. It is enabled by adding its Middleware to a Config. A
+// single Metrics can be shared by the configurations of multiple LStates.
+//
+// While a call is in progress, the pprof label "luar.func" is set to the short
+// name of the called function (e.g. "Claim.Adjudicate"), so that CPU profiles
+// attribute the time spent in bindings correctly.
+//
+// Example:
+//  metrics := NewMetrics()
+//  GetConfig(L).Use(metrics.Middleware)
+//  expvar.Publish("luar", metrics.Var())
+type Metrics struct {
+	mu    sync.Mutex
+	funcs map[string]*CallStats
+	types map[string]*CallStats
+}
+
+// CallStats holds the statistics of a function, method or type.
+type CallStats struct {
+	Calls  int64
+	Errors int64
+	// The total and maximum time spent in calls.
+	TotalTime time.Duration
+	MaxTime   time.Duration
+	// The approximate number of bytes allocated by luar for arguments and
+	// during calls (see CallInfo.AllocatedBytes).
+	AllocatedBytes int64
+}
+
+func (s *CallStats) add(elapsed time.Duration, failed bool, allocated int64) {
+	s.Calls++
+	if failed {
+		s.Errors++
+	}
+	s.TotalTime += elapsed
+	if elapsed > s.MaxTime {
+		s.MaxTime = elapsed
+	}
+	s.AllocatedBytes += allocated
+}
+
+// MetricsSnapshot is a point-in-time copy of the statistics of a Metrics.
+type MetricsSnapshot struct {
+	// Statistics per function or method, keyed by short name (e.g.
+	// "Claim.Adjudicate").
+	Funcs map[string]CallStats
+	// Statistics per receiver type of the called methods, keyed by type name
+	// (e.g. "*claims.Claim").
+	Types map[string]CallStats
+}
+
+// NewMetrics creates a new, empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		funcs: make(map[string]*CallStats),
+		types: make(map[string]*CallStats),
+	}
+}
+
+// Middleware is a middleware for Config.Use that records the statistics of
+// every call. Calls that return an error, or that end in a Lua error or a
+// panic, are counted as errors.
+func (m *Metrics) Middleware(next CallHandler) CallHandler {
+	return func(call *CallInfo) (err error) {
+		name := shortFuncName(call.Name)
+		ctx := call.L.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		start := time.Now()
+		defer func() {
+			// Lua errors (e.g. raised by bypass functions) unwind through
+			// the middleware, so they are recorded here.
+			rec := recover()
+			m.record(call, name, time.Since(start), err != nil || rec != nil)
+			if rec != nil {
+				panic(rec)
+			}
+		}()
+		pprof.Do(ctx, pprof.Labels("luar.func", name), func(context.Context) {
+			err = next(call)
+		})
+		return err
+	}
+}
+
+func (m *Metrics) record(call *CallInfo, name string, elapsed time.Duration, failed bool) {
+	allocated := call.AllocatedBytes()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.funcs[name]
+	if stats == nil {
+		stats = new(CallStats)
+		m.funcs[name] = stats
+	}
+	stats.add(elapsed, failed, allocated)
+	if call.Receiver != nil {
+		typeName := call.Receiver.String()
+		stats := m.types[typeName]
+		if stats == nil {
+			stats = new(CallStats)
+			m.types[typeName] = stats
+		}
+		stats.add(elapsed, failed, allocated)
+	}
+}
+
+// Snapshot returns a copy of the current statistics.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := MetricsSnapshot{
+		Funcs: make(map[string]CallStats, len(m.funcs)),
+		Types: make(map[string]CallStats, len(m.types)),
+	}
+	for name, stats := range m.funcs {
+		snapshot.Funcs[name] = *stats
+	}
+	for name, stats := range m.types {
+		snapshot.Types[name] = *stats
+	}
+	return snapshot
+}
+
+// Reset discards all statistics.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.funcs = make(map[string]*CallStats)
+	m.types = make(map[string]*CallStats)
+}
+
+// Var returns an expvar.Var that exports the current snapshot as JSON.
+func (m *Metrics) Var() expvar.Var {
+	return expvar.Func(func() interface{} {
+		return m.Snapshot()
+	})
+}
+
+// WritePrometheus writes the current statistics to w in the Prometheus text
+// exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	snapshot := m.Snapshot()
+
+	metrics := []struct {
+		name, kind, help string
+		value            func(s CallStats) string
+	}{
+		{"calls_total", "counter", "Number of calls from Lua.", func(s CallStats) string {
+			return fmt.Sprint(s.Calls)
+		}},
+		{"errors_total", "counter", "Number of calls from Lua that raised an error.", func(s CallStats) string {
+			return fmt.Sprint(s.Errors)
+		}},
+		{"call_seconds_total", "counter", "Total time spent in calls from Lua.", func(s CallStats) string {
+			return fmt.Sprint(s.TotalTime.Seconds())
+		}},
+		{"call_seconds_max", "gauge", "Maximum time spent in a call from Lua.", func(s CallStats) string {
+			return fmt.Sprint(s.MaxTime.Seconds())
+		}},
+		{"allocated_bytes_total", "counter", "Approximate bytes allocated by luar for calls from Lua.", func(s CallStats) string {
+			return fmt.Sprint(s.AllocatedBytes)
+		}},
+	}
+
+	groups := []struct {
+		prefix, label string
+		stats         map[string]CallStats
+	}{
+		{"luar_func_", "func", snapshot.Funcs},
+		{"luar_type_", "type", snapshot.Types},
+	}
+
+	for _, group := range groups {
+		names := make([]string, 0, len(group.stats))
+		for name := range group.stats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, metric := range metrics {
+			name := group.prefix + metric.name
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, metric.help, name, metric.kind); err != nil {
+				return err
+			}
+			for _, label := range names {
+				value := metric.value(group.stats[label])
+				if _, err := fmt.Fprintf(w, "%s{%s=\"%s\"} %s\n", name, group.label, prometheusEscaper.Replace(label), value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// shortFuncName returns the name of a function without its package path and
+// pointer receiver markers, e.g. "Claim.Adjudicate" for
+// "example.com/claims.(*Claim).Adjudicate".
+func shortFuncName(name string) string {
+	// The package path ends at the last slash before the qualified name;
+	// slashes after it are part of type arguments or function types.
+	qualified := name
+	if i := strings.IndexAny(qualified, "([ "); i >= 0 {
+		qualified = qualified[:i]
+	}
+	if i := strings.LastIndex(qualified, "/"); i >= 0 {
+		name = name[i+1:]
+		qualified = qualified[i+1:]
+	}
+	if i := strings.Index(qualified, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
@@ -0,0 +1,119 @@
+package luar
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_metrics(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	metrics := NewMetrics()
+	GetConfig(L).Use(metrics.Middleware)
+
+	p := &StructTestPerson{Name: "Tim"}
+	fail := func() {
+		panic("boom")
+	}
+	join := func(s []string) string {
+		return strings.Join(s, ",")
+	}
+
+	L.SetGlobal("p", New(L, p))
+	L.SetGlobal("fail", New(L, fail))
+	L.SetGlobal("join", New(L, join))
+
+	testReturn(t, L, `p:IncreaseAge(); p:IncreaseAge(); return p:Hello()`, "Hello, Tim")
+	testReturn(t, L, `return join({"a", "b"})`, "a,b")
+	testReturn(t, L, `return pcall(fail) == false`, "true")
+
+	snapshot := metrics.Snapshot()
+	if stats := snapshot.Funcs["StructTestPerson.IncreaseAge"]; stats.Calls != 2 || stats.Errors != 0 {
+		t.Fatalf("unexpected IncreaseAge stats %+v", stats)
+	}
+	if stats := snapshot.Types["*luar.StructTestPerson"]; stats.Calls != 3 || stats.TotalTime < stats.MaxTime {
+		t.Fatalf("unexpected type stats %+v", stats)
+	}
+	if stats := snapshot.Funcs["Test_metrics.func1"]; stats.Calls != 1 || stats.Errors != 1 {
+		t.Fatalf("unexpected fail stats %+v (%v)", stats, snapshot.Funcs)
+	}
+	if stats := snapshot.Funcs["Test_metrics.func2"]; stats.AllocatedBytes == 0 {
+		t.Fatalf("expecting allocated bytes for join, got %+v", stats)
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE luar_func_calls_total counter",
+		`luar_func_calls_total{func="StructTestPerson.IncreaseAge"} 2`,
+		`luar_func_errors_total{func="Test_metrics.func1"} 1`,
+		`luar_type_calls_total{type="*luar.StructTestPerson"} 3`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Fatalf("expecting line %q in:\n%s", line, buf.String())
+		}
+	}
+
+	if s := metrics.Var().String(); !strings.Contains(s, `"StructTestPerson.Hello"`) {
+		t.Fatalf("unexpected expvar value %s", s)
+	}
+}
+
+func Test_metrics_errors(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	metrics := NewMetrics()
+	config := GetConfig(L)
+	config.RaiseReturnedErrors = true
+	config.Use(metrics.Middleware)
+
+	raise := func(L *LState) int {
+		L.RaiseError("bypass error")
+		return 0
+	}
+	find := func(id int) (string, error) {
+		if id != 1 {
+			return "", errors.New("not found")
+		}
+		return "claim", nil
+	}
+
+	L.SetGlobal("raise", New(L, raise))
+	L.SetGlobal("find", New(L, find))
+
+	testReturn(t, L, `return pcall(raise) == false, find(1)`, "true", "claim")
+	testReturn(t, L, `return pcall(find, 2) == false`, "true")
+
+	snapshot := metrics.Snapshot()
+	if stats := snapshot.Funcs["Test_metrics_errors.func1"]; stats.Calls != 1 || stats.Errors != 1 {
+		t.Fatalf("unexpected raise stats %+v (%v)", stats, snapshot.Funcs)
+	}
+	if stats := snapshot.Funcs["Test_metrics_errors.func2"]; stats.Calls != 2 || stats.Errors != 1 {
+		t.Fatalf("unexpected find stats %+v", stats)
+	}
+}
+
+func Test_metrics_shortFuncName(t *testing.T) {
+	tbl := map[string]string{
+		"example.com/claims.(*Claim).Adjudicate":                 "Claim.Adjudicate",
+		"example.com/claims.Claim.Total":                         "Claim.Total",
+		"example.com/claims.Find":                                "Find",
+		"github.com/oscarhealth/gopher-luar.Test_metrics.func1":  "Test_metrics.func1",
+		"gopkg.in/yaml%2ev2.Marshal":                             "Marshal",
+		"luar.TestIfaceReader.Get":                               "TestIfaceReader.Get",
+		"claims.Reader[github.com/oscarhealth/claims.Claim].Get": "Reader[github.com/oscarhealth/claims.Claim].Get",
+	}
+	for name, expected := range tbl {
+		if actual := shortFuncName(name); actual != expected {
+			t.Errorf("shortFuncName(%q) = %q, expecting %q", name, actual, expected)
+		}
+	}
+}
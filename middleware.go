@@ -0,0 +1,78 @@
+package luar
+
+import (
+	"reflect"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// CallInfo describes a call of a Go function or method from Lua.
+type CallInfo struct {
+	L *lua.LState
+	// The name of the Go function, e.g. "main.(*Claim).Adjudicate".
+	Name string
+	// The receiver type of a method, or nil for functions.
+	Receiver reflect.Type
+	// The Go function. For methods, the first argument is the receiver.
+	Func reflect.Value
+	// The converted arguments. For bypass functions, the last argument is
+	// the *LState.
+	Args []reflect.Value
+	// The results of the call. These are set by the innermost handler, and
+	// can be replaced by middlewares.
+	Results []reflect.Value
+
+	allocated int64
+}
+
+// AllocatedBytes returns the approximate number of bytes that luar has
+// allocated on behalf of Lua code since the arguments of the call started
+// being converted (see Config.AllocatedBytes).
+func (call *CallInfo) AllocatedBytes() int64 {
+	return GetConfig(call.L).allocated - call.allocated
+}
+
+// CallHandler handles a call of a Go function from Lua. A returned error is
+// raised as a Lua error (see Raise); a *PanicError is raised as its message.
+type CallHandler func(call *CallInfo) error
+
+// Use adds a middleware to the chain of handlers that every call of a Go
+// function or method from Lua passes through. Middlewares are called in the
+// order they were added. A middleware can short-circuit the call by returning
+// an error or by setting call.Results without calling next. If the results
+// do not match the results of the Go function after the chain returns, a Lua
+// error is raised. Lua errors raised during the call, e.g. by bypass
+// functions, unwind through the middlewares as panics, so middlewares that
+// must observe every call use defer.
+//
+// Example:
+//  GetConfig(L).Use(func(next CallHandler) CallHandler {
+//    return func(call *CallInfo) error {
+//      start := time.Now()
+//      err := next(call)
+//      log.Printf("%s took %s", call.Name, time.Since(start))
+//      return err
+//    }
+//  })
+func (c *Config) Use(middleware func(next CallHandler) CallHandler) {
+	c.mustNotBeFrozen("Use")
+	c.middlewares = append(c.middlewares, middleware)
+
+	handler := CallHandler(callHandler)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		handler = c.middlewares[i](handler)
+	}
+	c.handler = handler
+}
+
+// callHandler is the innermost CallHandler, which calls the Go function. With
+// Config.RaiseReturnedErrors, a non-nil error returned by the function is
+// also returned by callHandler, so that middlewares see the call as failed.
+func callHandler(call *CallInfo) error {
+	results, err := callFuncRecover(call.L, call.Func, call.Args)
+	if err != nil {
+		return err
+	}
+	call.Results = results
+	return returnedError(GetConfig(call.L), call.Func.Type(), results)
+}
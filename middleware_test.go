@@ -0,0 +1,136 @@
+package luar
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_middleware(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	var calls []string
+	config := GetConfig(L)
+	config.Use(func(next CallHandler) CallHandler {
+		return func(call *CallInfo) error {
+			calls = append(calls, "outer")
+			return next(call)
+		}
+	})
+	config.Use(func(next CallHandler) CallHandler {
+		return func(call *CallInfo) error {
+			receiver := "<nil>"
+			if call.Receiver != nil {
+				receiver = call.Receiver.String()
+			}
+			calls = append(calls, receiver+" "+call.Name[strings.LastIndex(call.Name, ".")+1:])
+			return next(call)
+		}
+	})
+
+	p := &StructTestPerson{Name: "Tim"}
+	L.SetGlobal("p", New(L, p))
+
+	testReturn(t, L, `return p:Hello()`, "Hello, Tim")
+	testReturn(t, L, `return p:AddNumbers(1, 2)`, "Tim counts: 3")
+
+	expected := []string{
+		"outer", "*luar.StructTestPerson Hello",
+		"outer", "*luar.StructTestPerson AddNumbers",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expecting calls %v, got %v", expected, calls)
+	}
+}
+
+func Test_middleware_shortcircuit(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	errRateLimited := errors.New("rate limited")
+	called := 0
+	config := GetConfig(L)
+	config.Use(func(next CallHandler) CallHandler {
+		return func(call *CallInfo) error {
+			switch call.Args[0].Interface() {
+			case "limited":
+				return errRateLimited
+			case "cached":
+				call.Results = []reflect.Value{reflect.ValueOf("from cache")}
+				return nil
+			}
+			return next(call)
+		}
+	})
+
+	fn := func(s string) string {
+		called++
+		return "hello " + s
+	}
+	L.SetGlobal("fn", New(L, fn))
+
+	testReturn(t, L, `return fn("world")`, "hello world")
+	testReturn(t, L, `return fn("cached")`, "from cache")
+	if err := WrapError(L.DoString(`fn("limited")`)); !errors.Is(err, errRateLimited) {
+		t.Fatalf("expecting errRateLimited, got %v", err)
+	}
+	if called != 1 {
+		t.Fatalf("expecting fn to be called once, got %d", called)
+	}
+}
+
+func Test_middleware_invalid_results(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	GetConfig(L).Use(func(next CallHandler) CallHandler {
+		return func(call *CallInfo) error {
+			switch call.Args[0].Interface() {
+			case "none":
+				return nil
+			case "invalid":
+				call.Results = []reflect.Value{{}}
+			case "int":
+				call.Results = []reflect.Value{reflect.ValueOf(1)}
+			}
+			return nil
+		}
+	})
+
+	fn := func(s string) string {
+		return s
+	}
+	L.SetGlobal("fn", New(L, fn))
+
+	testError(t, L, `fn("none")`, "invalid number of results for")
+	testError(t, L, `fn("invalid")`, "invalid result 1 for")
+	testError(t, L, `fn("int")`, "(expected string, got int)")
+}
+
+func Test_middleware_panic(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	var seen error
+	GetConfig(L).Use(func(next CallHandler) CallHandler {
+		return func(call *CallInfo) error {
+			seen = next(call)
+			return seen
+		}
+	})
+
+	fn := func() {
+		panic("boom")
+	}
+	L.SetGlobal("fn", New(L, fn))
+
+	err := WrapError(L.DoString(`fn()`))
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" || seen != panicErr {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
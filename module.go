@@ -0,0 +1,55 @@
+package luar
+
+import (
+	"github.com/yuin/gopher-lua"
+)
+
+// Loader is a gopher-lua module loader for the "luar" module, which provides
+// Lua functions for working with luar values:
+//  freeze(v):    Returns an immutable view of v (see Freeze).
+//  isfrozen(v):  Returns true if v is an immutable luar value.
+//  isluar(v):    Returns true if v is a luar value.
+//  typeof(v):    Returns the Go type of v as a string, e.g. "*main.Claim".
+//  kind(v):      Returns the kind of v's Go type, e.g. "struct", "ptr",
+//                "interface" (for NewAs), "type" (for NewType) or "opaque".
+//  isptr(v):     Returns true if v is a pointer.
+//  fields(v):    Returns the names of the fields of a struct or pointer to
+//                struct, in declaration order.
+//  methods(v):   Returns the sorted names of the methods that can be called
+//                on v.
+//  options(v):   Returns the ReflectOptions of v as a table, e.g.
+//                {immutable = true, opaque = false, ...}.
+//  dump(v, [depth]): Returns a multi-line representation of v that shows
+//                nested structs, maps and slices up to the given depth
+//                (default 5). Cycles are marked with "<cycle Type>".
+//
+// typeof, kind, fields, methods and options return nil for values that were
+// not created by luar, and typeof, fields and methods return nil for opaque
+// values.
+// Names are the first names returned by Config.FieldNames and
+// Config.MethodNames, and members that the access policy does not allow to be
+// read or called are omitted, as are mutating methods of immutable values.
+//
+// Example:
+//  L.PreloadModule("luar", luar.Loader)
+//  ---
+//  local luar = require("luar")
+//  local readonly = luar.freeze(claim)
+func Loader(L *lua.LState) int {
+	mod := L.SetFuncs(L.NewTable(), moduleFuncs)
+	L.Push(mod)
+	return 1
+}
+
+var moduleFuncs = map[string]lua.LGFunction{
+	"freeze":   luaFreeze,
+	"isfrozen": luaIsFrozen,
+	"isluar":   luaIsLuar,
+	"typeof":   luaTypeof,
+	"kind":     luaKind,
+	"isptr":    luaIsPtr,
+	"fields":   luaFields,
+	"methods":  luaMethods,
+	"options":  luaOptions,
+	"dump":     luaDump,
+}
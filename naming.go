@@ -0,0 +1,189 @@
+package luar
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// SnakeCaseFieldNames can be used as Config.FieldNames. It accesses fields
+// under their snake_case name (e.g. "member_id" for MemberID). Like the
+// default behaviour, the "luar" tag of a field overrides its name, and the
+// tag "-" makes the field inaccessible.
+func SnakeCaseFieldNames(s reflect.Type, f reflect.StructField) []string {
+	return tagFieldNames(f, snakeCase)
+}
+
+// SnakeCaseMethodNames can be used as Config.MethodNames. It accesses methods
+// under their snake_case name (e.g. "get_claims" for GetClaims).
+func SnakeCaseMethodNames(t reflect.Type, m reflect.Method) []string {
+	return []string{snakeCase(m.Name)}
+}
+
+// CamelCaseFieldNames can be used as Config.FieldNames. It accesses fields
+// under their camelCase name (e.g. "memberID" for MemberID, "httpServer" for
+// HTTPServer). Like the default behaviour, the "luar" tag of a field
+// overrides its name, and the tag "-" makes the field inaccessible.
+func CamelCaseFieldNames(s reflect.Type, f reflect.StructField) []string {
+	return tagFieldNames(f, camelCase)
+}
+
+// CamelCaseMethodNames can be used as Config.MethodNames. It accesses methods
+// under their camelCase name (e.g. "getClaims" for GetClaims).
+func CamelCaseMethodNames(t reflect.Type, m reflect.Method) []string {
+	return []string{camelCase(m.Name)}
+}
+
+// JSONFieldNames returns a function that can be used as Config.FieldNames.
+// Fields are accessed under the name of their "json" tag. Fields without a
+// json tag name, or with the json tag "-", are named by fallback, or by the
+// default behaviour if fallback is nil. The "luar" tag of a field takes
+// precedence over its json tag.
+//
+// Example:
+//  GetConfig(L).FieldNames = JSONFieldNames(SnakeCaseFieldNames)
+func JSONFieldNames(fallback func(s reflect.Type, f reflect.StructField) []string) func(s reflect.Type, f reflect.StructField) []string {
+	if fallback == nil {
+		fallback = defaultFieldNames
+	}
+	return func(s reflect.Type, f reflect.StructField) []string {
+		if _, ok := f.Tag.Lookup("luar"); !ok {
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name != "" && name != "-" {
+				return []string{name}
+			}
+		}
+		return fallback(s, f)
+	}
+}
+
+// tagFieldNames returns the name in the "luar" tag of f, if any, or the Go
+// name of f converted by convert.
+func tagFieldNames(f reflect.StructField, convert func(name string) string) []string {
+	switch tag := f.Tag.Get("luar"); tag {
+	case "-":
+		return nil
+	case "":
+		return []string{convert(f.Name)}
+	default:
+		return []string{tag}
+	}
+}
+
+// snakeCase converts a Go name to snake_case. Acronyms are kept together,
+// e.g. "HTTPServer" becomes "http_server".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// camelCase converts a Go name to camelCase by lowercasing its leading
+// uppercase letters, e.g. "HTTPServer" becomes "httpServer".
+func camelCase(name string) string {
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
+
+// NameCollision describes a Lua name that was generated for more than one
+// field or method of a type. It is passed to Config.NameCollisionHandler.
+type NameCollision struct {
+	// The struct type for fields, or the receiver type for methods.
+	Type reflect.Type
+	// The colliding Lua name.
+	Name string
+	// The Go name of the member that the Lua name refers to.
+	Kept string
+	// The Go name of the member that cannot be accessed under the Lua name.
+	Dropped string
+}
+
+func (c *Config) nameCollision(t reflect.Type, name, kept, dropped string) {
+	if handler := c.settings().NameCollisionHandler; handler != nil {
+		handler(NameCollision{
+			Type:    t,
+			Name:    name,
+			Kept:    kept,
+			Dropped: dropped,
+		})
+	}
+}
+
+// luaNames returns the given names and, if Config.CaseInsensitiveNames is
+// set, their lowercase variants.
+func (c *Config) luaNames(names []string) []string {
+	if !c.settings().CaseInsensitiveNames {
+		return names
+	}
+	all := append([]string(nil), names...)
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		if !containsString(all, lower) {
+			all = append(all, lower)
+		}
+	}
+	return all
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// memberName returns the Go name of the field or method that value refers to
+// in a metatable, or key for built-in methods.
+func memberName(t reflect.Type, key string, value lua.LValue) string {
+	switch converted := value.(type) {
+	case *lua.LUserData:
+		if index, ok := converted.Value.([]int); ok {
+			return t.FieldByIndex(index).Name
+		}
+	case *lua.LFunction:
+		if info := funcMethodInfo(converted); info != nil {
+			return info.Method.Name
+		}
+	}
+	return key
+}
+
+// checkMemberCollisions reports the names of the fields of a struct metatable
+// that are hidden by methods.
+func checkMemberCollisions(c *Config, vtype reflect.Type, fields, methods, ptrMethods *lua.LTable) {
+	if c.settings().NameCollisionHandler == nil {
+		return
+	}
+	fields.ForEach(func(key, value lua.LValue) {
+		name := key.String()
+		for _, tbl := range []*lua.LTable{methods, ptrMethods} {
+			if fn := tbl.RawGetString(name); fn != lua.LNil {
+				c.nameCollision(vtype, name, memberName(vtype, name, fn), memberName(vtype, name, value))
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package luar
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+func Test_naming_cases(t *testing.T) {
+	tbl := []struct {
+		Name, Snake, Camel string
+	}{
+		{"ID", "id", "id"},
+		{"MemberID", "member_id", "memberID"},
+		{"GetClaims", "get_claims", "getClaims"},
+		{"HTTPServer", "http_server", "httpServer"},
+		{"Version2Name", "version2_name", "version2Name"},
+		{"A", "a", "a"},
+	}
+	for _, test := range tbl {
+		if snake := snakeCase(test.Name); snake != test.Snake {
+			t.Errorf("snakeCase(%q) = %q, expecting %q", test.Name, snake, test.Snake)
+		}
+		if camel := camelCase(test.Name); camel != test.Camel {
+			t.Errorf("camelCase(%q) = %q, expecting %q", test.Name, camel, test.Camel)
+		}
+	}
+}
+
+type NamingTestMember struct {
+	MemberID int
+	FullName string `json:"full_name,omitempty"`
+	Status   string `json:"-"`
+	Note     string `luar:"memo" json:"note"`
+	Secret   string `luar:"-"`
+}
+
+func (m NamingTestMember) GetClaims() int {
+	return m.MemberID * 2
+}
+
+func Test_naming_strategies(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.FieldNames = JSONFieldNames(SnakeCaseFieldNames)
+	config.MethodNames = SnakeCaseMethodNames
+
+	L.SetGlobal("m", New(L, &NamingTestMember{MemberID: 4, FullName: "Tim", Status: "active", Note: "n"}))
+
+	testReturn(t, L, `return m.member_id, m.full_name, m.status, m.memo, m.note, m.secret`, "4", "Tim", "active", "n", "nil", "nil")
+	testReturn(t, L, `return m.MemberID, m:get_claims()`, "nil", "8")
+
+	L2 := lua.NewState()
+	defer L2.Close()
+	config = GetConfig(L2)
+	config.FieldNames = CamelCaseFieldNames
+	config.MethodNames = CamelCaseMethodNames
+
+	L2.SetGlobal("m", New(L2, &NamingTestMember{MemberID: 4, FullName: "Tim"}))
+
+	testReturn(t, L2, `return m.memberID, m.fullName, m:getClaims(), m.full_name`, "4", "Tim", "8", "nil")
+}
+
+func Test_naming_case_insensitive(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	GetConfig(L).CaseInsensitiveNames = true
+
+	L.SetGlobal("m", New(L, &NamingTestMember{MemberID: 4}))
+
+	testReturn(t, L, `return m.MemberID, m.memberid, m.MEMBERID, m:GETCLAIMS()`, "4", "4", "4", "8")
+	testReturn(t, L, `m.MEMBERID = 5; return m.memberID`, "5")
+}
+
+type NamingTestCollision struct {
+	MemberID int
+	MemberId int
+	Total    int
+	NamingTestEmbedded
+}
+
+type NamingTestEmbedded struct {
+	MemberID int
+	Other    int
+}
+
+func (NamingTestCollision) Get_total() int {
+	return 0
+}
+
+func Test_naming_collisions(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	var collisions []NameCollision
+	config := GetConfig(L)
+	config.FieldNames = SnakeCaseFieldNames
+	config.MethodNames = func(t reflect.Type, m reflect.Method) []string {
+		return []string{"total"}
+	}
+	config.NameCollisionHandler = func(collision NameCollision) {
+		collisions = append(collisions, collision)
+	}
+
+	L.SetGlobal("c", New(L, &NamingTestCollision{MemberID: 1, MemberId: 2, Total: 3}))
+
+	testReturn(t, L, `return c.member_id, c.other`, "1", "0")
+
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].Dropped < collisions[j].Dropped
+	})
+	expected := []NameCollision{
+		{Type: reflect.TypeOf(NamingTestCollision{}), Name: "member_id", Kept: "MemberID", Dropped: "MemberId"},
+		{Type: reflect.TypeOf(NamingTestCollision{}), Name: "total", Kept: "Get_total", Dropped: "Total"},
+	}
+	if !reflect.DeepEqual(collisions, expected) {
+		t.Fatalf("unexpected collisions %+v", collisions)
+	}
+}
@@ -20,7 +20,7 @@ func checkPtr(L *lua.LState, idx int) (ref reflect.Value, opts ReflectOptions, m
 	if ref.Kind() != kind {
 		L.ArgError(idx, "expecting "+kind.String())
 	}
-	mt = &Metatable{LTable: ud.Metatable.(*lua.LTable)}
+	mt = userDataMetatable(L, ud)
 	return
 }
 
@@ -53,6 +53,7 @@ func ptrPow(L *lua.LState) int {
 	if ref.IsNil() {
 		L.RaiseError("cannot dereference nil pointer")
 	}
+	checkAccess(L, ref.Type(), "", AccessWrite)
 	elem := ref.Elem()
 	if !elem.CanSet() {
 		L.RaiseError("unable to set pointer value")
@@ -67,18 +68,19 @@ func ptrPow(L *lua.LState) int {
 
 func ptrUnm(L *lua.LState) int {
 	ref, opts, _ := checkPtr(L, 1)
+	checkAccess(L, ref.Type(), "", AccessRead)
 	elem := ref.Elem()
 	if !elem.CanInterface() {
-		L.RaiseError("cannot interface pointer type " + elem.String())
+		L.RaiseError("cannot interface pointer type %s", elem)
 	}
 	L.Push(New(L, elem.Interface(), opts))
 	return 1
 }
 
 func ptrEq(L *lua.LState) int {
-	ref1, _, _ := checkPtr(L, 1)
-	ref2, _, _ := checkPtr(L, 1)
+	checkPtr(L, 1)
+	checkPtr(L, 2)
 
-	L.Push(lua.LBool(ref1.Pointer() == ref2.Pointer()))
+	L.Push(lua.LBool(valuesEqual(L, L.CheckUserData(1), L.CheckUserData(2), EqualityIdentity)))
 	return 1
 }
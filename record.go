@@ -0,0 +1,285 @@
+package luar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// RecordedCall is a call of a Go function or method from Lua, as written by a
+// Recorder and read by a Replayer. Recordings are stored as JSON lines, one
+// RecordedCall per line.
+type RecordedCall struct {
+	// The name of the Go function (see CallInfo.Name).
+	Name string `json:"name"`
+	// The arguments of the call. For methods, the first argument is the
+	// receiver.
+	Args []RecordedValue `json:"args"`
+	// The results of the call. Empty if the call failed.
+	Results []RecordedValue `json:"results,omitempty"`
+	// The message of the error raised by the call, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// RecordedValue is a Go value in a recording, tagged with its type.
+type RecordedValue struct {
+	// The Go type of the value, e.g. "*main.Claim".
+	Type string `json:"type"`
+	// The JSON encoding of the value. Absent if the value cannot be encoded
+	// as JSON (e.g. channels and functions).
+	Value json.RawMessage `json:"value,omitempty"`
+	// The message of a non-nil error value.
+	Error string `json:"error,omitempty"`
+	// The string representation of a value that cannot be encoded as JSON.
+	Repr string `json:"repr,omitempty"`
+}
+
+// newRecordedValue encodes v.
+func newRecordedValue(v reflect.Value) RecordedValue {
+	if !v.IsValid() {
+		return RecordedValue{
+			Type:  "nil",
+			Value: json.RawMessage("null"),
+		}
+	}
+
+	recorded := RecordedValue{
+		Type: v.Type().String(),
+	}
+	if v.Type() == refTypeLStatePtr {
+		return recorded
+	}
+	if v.Type().Implements(refTypeError) && !isNilValue(v) {
+		recorded.Error = v.Interface().(error).Error()
+		return recorded
+	}
+	if !v.CanInterface() {
+		recorded.Repr = v.String()
+		return recorded
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		recorded.Repr = fmt.Sprint(v.Interface())
+		return recorded
+	}
+	recorded.Value = data
+	return recorded
+}
+
+// isNilValue returns true if v is a nil channel, function, interface, map,
+// pointer or slice.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// decode converts the recorded value to a value of type t. Errors are decoded
+// using errors.New, and values that could not be encoded are decoded as the
+// zero value of t.
+func (recorded RecordedValue) decode(t reflect.Type) (reflect.Value, error) {
+	value := reflect.New(t).Elem()
+	switch {
+	case recorded.Error != "":
+		err := reflect.ValueOf(errors.New(recorded.Error))
+		if !err.Type().AssignableTo(t) {
+			return value, fmt.Errorf("cannot replay error as %s", t)
+		}
+		value.Set(err)
+	case len(recorded.Value) > 0:
+		if err := json.Unmarshal(recorded.Value, value.Addr().Interface()); err != nil {
+			return value, fmt.Errorf("cannot replay %s as %s: %s", recorded.Type, t, err)
+		}
+	}
+	return value, nil
+}
+
+// Recorder records the Go calls made from Lua, so that they can be replayed
+// later using a Replayer. It is enabled by adding its Middleware to a Config.
+//
+// Arguments and results are encoded as JSON. Values that cannot be encoded
+// (e.g. channels and functions) are recorded using their string
+// representation, and are replayed as zero values.
+//
+// Example:
+//  f, err := os.Create("calls.jsonl")
+//  ...
+//  recorder := luar.NewRecorder(f)
+//  luar.GetConfig(L).Use(recorder.Middleware)
+//  err = L.DoString(script)
+//  ...
+//  if err := recorder.Err(); err != nil {
+//    ...
+//  }
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder creates a new Recorder that writes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Middleware is a middleware for Config.Use that records every call. Calls
+// that end in a Lua error, e.g. raised by a bypass function, are recorded with
+// the message of the error, so that replays stay in step.
+func (r *Recorder) Middleware(next CallHandler) CallHandler {
+	return func(call *CallInfo) (err error) {
+		recorded := RecordedCall{
+			Name: call.Name,
+			Args: make([]RecordedValue, len(call.Args)),
+		}
+		for i, arg := range call.Args {
+			recorded.Args[i] = newRecordedValue(arg)
+		}
+
+		defer func() {
+			rec := recover()
+			switch {
+			case rec != nil:
+				recorded.Error = panicMessage(rec)
+			case err != nil:
+				recorded.Error = err.Error()
+			default:
+				recorded.Results = make([]RecordedValue, len(call.Results))
+				for i, result := range call.Results {
+					recorded.Results[i] = newRecordedValue(result)
+				}
+			}
+			r.write(recorded)
+			if rec != nil {
+				panic(rec)
+			}
+		}()
+		return next(call)
+	}
+}
+
+func (r *Recorder) write(recorded RecordedCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.enc.Encode(recorded)
+	}
+}
+
+// panicMessage returns the message of a Lua error or panic that unwound
+// through a middleware.
+func panicMessage(rec interface{}) string {
+	if apiErr, ok := rec.(*lua.ApiError); ok {
+		if err := errorFromLValue(apiErr.Object); err != nil {
+			return err.Error()
+		}
+		return apiErr.Object.String()
+	}
+	return fmt.Sprint(rec)
+}
+
+// Err returns the first error that occurred while writing the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Replayer replays the Go calls recorded by a Recorder. It is enabled by
+// adding its Middleware to a Config. The Go functions are not called; instead,
+// each call returns the results or raises the error of the next recorded
+// call. Calls must be made in the order in which they were recorded.
+//
+// Bypass functions (see LState) cannot be replayed, as the values they push
+// onto the Lua stack are not recorded; only the errors they raised are.
+//
+// Example:
+//  f, err := os.Open("calls.jsonl")
+//  ...
+//  replayer, err := luar.NewReplayer(f)
+//  ...
+//  luar.GetConfig(L).Use(replayer.Middleware)
+//  err = L.DoString(script)
+type Replayer struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+	next  int
+}
+
+// NewReplayer creates a new Replayer that reads the recording from r.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	replayer := &Replayer{}
+	dec := json.NewDecoder(r)
+	for {
+		var call RecordedCall
+		if err := dec.Decode(&call); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		replayer.calls = append(replayer.calls, call)
+	}
+	return replayer, nil
+}
+
+// Remaining returns the number of recorded calls that have not been replayed.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.calls) - r.next
+}
+
+// Middleware is a middleware for Config.Use that replays the recorded calls.
+// It does not call next.
+func (r *Replayer) Middleware(next CallHandler) CallHandler {
+	return func(call *CallInfo) error {
+		recorded, err := r.pop(call.Name)
+		if err != nil {
+			return err
+		}
+
+		if recorded.Error != "" {
+			return errors.New(recorded.Error)
+		}
+		fnType := call.Func.Type()
+		if fnType.NumIn() > 0 && fnType.In(fnType.NumIn()-1) == refTypeLStatePtr {
+			return fmt.Errorf("replay: cannot replay bypass function %s", call.Name)
+		}
+		if len(recorded.Results) != fnType.NumOut() {
+			return fmt.Errorf("replay: %s recorded with %d results, expecting %d", call.Name, len(recorded.Results), fnType.NumOut())
+		}
+
+		results := make([]reflect.Value, fnType.NumOut())
+		for i := range results {
+			if results[i], err = recorded.Results[i].decode(fnType.Out(i)); err != nil {
+				return fmt.Errorf("replay: %s: %s", call.Name, err)
+			}
+		}
+		call.Results = results
+		return nil
+	}
+}
+
+// pop returns the next recorded call, which must be a call to name.
+func (r *Replayer) pop(name string) (RecordedCall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next >= len(r.calls) {
+		return RecordedCall{}, fmt.Errorf("replay: unexpected call to %s", name)
+	}
+	recorded := r.calls[r.next]
+	if recorded.Name != name {
+		return RecordedCall{}, fmt.Errorf("replay: expecting call to %s, got %s", recorded.Name, name)
+	}
+	r.next++
+	return recorded, nil
+}
@@ -0,0 +1,108 @@
+package luar
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type recordTestClaim struct {
+	ID     int
+	Amount float64
+}
+
+func (c *recordTestClaim) Adjudicate(factor float64) (float64, error) {
+	if factor < 0 {
+		return 0, errors.New("negative factor")
+	}
+	return c.Amount * factor, nil
+}
+
+// newRecordTestState creates an LState for the record and replay tests. The
+// functions are created here so that their names are the same in both runs.
+func newRecordTestState(middleware func(CallHandler) CallHandler, calls *int) *lua.LState {
+	L := lua.NewState()
+	config := GetConfig(L)
+	config.RaiseReturnedErrors = true
+	config.Use(middleware)
+
+	L.SetGlobal("claim", New(L, &recordTestClaim{ID: 1, Amount: 100}))
+	L.SetGlobal("find", New(L, func(id int) *recordTestClaim {
+		*calls++
+		return &recordTestClaim{ID: id, Amount: 42}
+	}))
+	L.SetGlobal("check", New(L, func(L *LState) int {
+		*calls++
+		L.RaiseError("check failed")
+		return 0
+	}))
+	L.SetGlobal("names", New(L, func() []string {
+		*calls++
+		return []string{"a", "b", "c"}
+	}))
+	return L
+}
+
+func Test_record_replay(t *testing.T) {
+	const script = `
+	local paid = claim:Adjudicate(0.5)
+	local ok, err = pcall(function() claim:Adjudicate(-1) end)
+	local checked, checkErr = pcall(check)
+	local found = find(12)
+	return paid, tostring(err), tostring(checkErr):match("check failed"), found.ID, found.Amount, #names()
+	`
+
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	calls := 0
+	L := newRecordTestState(recorder.Middleware, &calls)
+	defer L.Close()
+
+	testReturn(t, L, script, "50", "negative factor", "check failed", "12", "42", "3")
+	if err := recorder.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 5 || calls != 3 {
+		t.Fatalf("expecting 5 recorded calls, got:\n%s", buf.String())
+	}
+
+	replayer, err := NewReplayer(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls = 0
+	L = newRecordTestState(replayer.Middleware, &calls)
+	defer L.Close()
+
+	testReturn(t, L, script, "50", "negative factor", "check failed", "12", "42", "3")
+	if calls != 0 {
+		t.Fatalf("expecting no calls during replay, got %d", calls)
+	}
+	if remaining := replayer.Remaining(); remaining != 0 {
+		t.Fatalf("expecting all calls to be replayed, %d remaining", remaining)
+	}
+	if err := WrapError(L.DoString(`names()`)); err == nil || !strings.Contains(err.Error(), "replay: unexpected call to") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func Test_record_replay_mismatch(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	replayer, err := NewReplayer(strings.NewReader(`{"name":"main.find","args":[],"results":[]}` + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	GetConfig(L).Use(replayer.Middleware)
+
+	L.SetGlobal("fn", New(L, func() {}))
+
+	err = WrapError(L.DoString(`fn()`))
+	if err == nil || !strings.Contains(err.Error(), "replay: expecting call to main.find") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
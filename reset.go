@@ -0,0 +1,181 @@
+package luar
+
+import (
+	"reflect"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// Invalidate discards the cached metatables of the given types, so that
+// changes to the configuration (e.g. FieldNames, MethodNames or bindings)
+// apply to them. Pointer types are invalidated as their element type. The
+// metatables are rebuilt in place when they are next used, so userdata that
+// already exist are updated as well. Remembered access policy decisions (see
+// CacheAccessPolicy) for the types are discarded too.
+//
+// Invalidate panics if the configuration is frozen.
+func (c *Config) Invalidate(types ...reflect.Type) {
+	c.mustNotBeFrozen("Invalidate")
+	for _, t := range types {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		c.invalidate(t)
+	}
+}
+
+// Reset is like Invalidate, but applies to every type that has been converted
+// so far.
+//
+// Reset panics if the configuration is frozen.
+func (c *Config) Reset() {
+	c.mustNotBeFrozen("Reset")
+	for t := range c.regular {
+		c.invalidate(t)
+	}
+	c.allowed = make(map[accessKey]struct{})
+}
+
+func (c *Config) invalidate(t reflect.Type) {
+	if mt := c.regular[t]; mt != nil {
+		if c.stale == nil {
+			c.stale = make(map[*lua.LTable]reflect.Type)
+		}
+		c.stale[mt] = t
+	}
+	for key := range c.allowed {
+		if key.Type == t || (key.Type.Kind() == reflect.Ptr && key.Type.Elem() == t) {
+			delete(c.allowed, key)
+		}
+	}
+}
+
+func (c *Config) isStale(mt *lua.LTable) bool {
+	if len(c.stale) == 0 {
+		return false
+	}
+	_, ok := c.stale[mt]
+	return ok
+}
+
+// rebuildMetatable replaces the contents of the metatable mt of vtype with a
+// newly built metatable, so that the userdata that use mt are updated.
+func (c *Config) rebuildMetatable(L *lua.LState, vtype reflect.Type, mt *lua.LTable) {
+	delete(c.stale, mt)
+	members := metatableStructMembers(mt)
+	fresh := newMetatable(L, c, vtype)
+
+	var keys []lua.LValue
+	mt.ForEach(func(key, _ lua.LValue) {
+		keys = append(keys, key)
+	})
+	for _, key := range keys {
+		mt.RawSet(key, lua.LNil)
+	}
+	fresh.ForEach(func(key, value lua.LValue) {
+		mt.RawSet(key, value)
+	})
+	// The __index and __newindex functions that are running may still refer
+	// to the old members.
+	if members != nil {
+		*members = *metatableStructMembers(fresh)
+	}
+}
+
+// Freeze marks the end of the setup of the configuration. Afterwards, Use,
+// BindType, LoadBindings, ApplyBindings, Invalidate and Reset panic. The
+// exported fields of the configuration (e.g. AccessPolicy and MaxAllocBytes)
+// are copied by Freeze, and luar only uses the copy afterwards; if one of the
+// fields is changed later, the next use of the configuration panics.
+//
+// Freeze is unrelated to the Freeze function, which makes a single value
+// immutable.
+func (c *Config) Freeze() {
+	if c.frozen != nil {
+		return
+	}
+	settings := &Config{}
+	src, dst := reflect.ValueOf(c).Elem(), reflect.ValueOf(settings).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		if src.Type().Field(i).PkgPath == "" {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	c.frozen = settings
+}
+
+// Frozen returns true if Freeze has been called.
+func (c *Config) Frozen() bool {
+	return c.frozen != nil
+}
+
+func (c *Config) mustNotBeFrozen(op string) {
+	if c.frozen != nil {
+		panic("luar: " + op + " called on a frozen configuration")
+	}
+}
+
+// settings returns the configuration whose exported fields are in effect: the
+// copy made by Freeze, or c itself if c is not frozen. It panics if an
+// exported field of a frozen configuration has been changed.
+func (c *Config) settings() *Config {
+	if c.frozen != nil {
+		if field := c.changedSetting(); field != "" {
+			panic("luar: configuration field " + field + " changed after Freeze")
+		}
+		return c.frozen
+	}
+	return c
+}
+
+// changedSetting returns the name of the first exported field of c that
+// differs from the copy made by Freeze, or "". Functions are compared by
+// their code pointer (nil for nil functions). The fields are listed by hand
+// rather than iterated using reflect, because settings is on the hot paths.
+func (c *Config) changedSetting() string {
+	f := c.frozen
+	switch {
+	case !sameFunc(c.FieldNames, f.FieldNames):
+		return "FieldNames"
+	case !sameFunc(c.MethodNames, f.MethodNames):
+		return "MethodNames"
+	case c.CaseInsensitiveNames != f.CaseInsensitiveNames:
+		return "CaseInsensitiveNames"
+	case !sameFunc(c.NameCollisionHandler, f.NameCollisionHandler):
+		return "NameCollisionHandler"
+	case !sameFunc(c.MethodPolicy, f.MethodPolicy):
+		return "MethodPolicy"
+	case !sameFunc(c.AccessPolicy, f.AccessPolicy):
+		return "AccessPolicy"
+	case c.CacheAccessPolicy != f.CacheAccessPolicy:
+		return "CacheAccessPolicy"
+	case !sameFunc(c.ExposedTypes, f.ExposedTypes):
+		return "ExposedTypes"
+	case c.MaxSliceLength != f.MaxSliceLength:
+		return "MaxSliceLength"
+	case c.MaxMapEntries != f.MaxMapEntries:
+		return "MaxMapEntries"
+	case c.MaxChanBuffer != f.MaxChanBuffer:
+		return "MaxChanBuffer"
+	case c.MaxAllocBytes != f.MaxAllocBytes:
+		return "MaxAllocBytes"
+	case !sameFunc(c.PanicHandler, f.PanicHandler):
+		return "PanicHandler"
+	case c.PanicStack != f.PanicStack:
+		return "PanicStack"
+	case c.RaiseReturnedErrors != f.RaiseReturnedErrors:
+		return "RaiseReturnedErrors"
+	case !sameFunc(c.Tostring, f.Tostring):
+		return "Tostring"
+	case !sameFunc(c.Equality, f.Equality):
+		return "Equality"
+	case c.IdentityCache != f.IdentityCache:
+		return "IdentityCache"
+	}
+	return ""
+}
+
+// sameFunc returns true if the functions a and b have the same code pointer.
+func sameFunc(a, b interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
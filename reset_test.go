@@ -0,0 +1,146 @@
+package luar
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type ResetTestPerson struct {
+	FirstName string
+}
+
+func (p ResetTestPerson) Greet() string {
+	return "hi " + p.FirstName
+}
+
+type ResetTestPet struct {
+	Name string
+}
+
+func Test_config_invalidate(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	L.SetGlobal("p", New(L, &ResetTestPerson{FirstName: "Tim"}))
+	L.SetGlobal("pet", New(L, &ResetTestPet{Name: "Rex"}))
+
+	testReturn(t, L, `return p.FirstName, p.first_name, p:Greet()`, "Tim", "nil", "hi Tim")
+
+	config.FieldNames = SnakeCaseFieldNames
+	config.MethodNames = SnakeCaseMethodNames
+	testReturn(t, L, `return p.FirstName, p.first_name`, "Tim", "nil")
+
+	config.Invalidate(reflect.TypeOf(&ResetTestPerson{}))
+	testReturn(t, L, `return p.FirstName, p.first_name, p:greet(), pet.Name`, "nil", "Tim", "hi Tim", "Rex")
+
+	L.SetGlobal("q", New(L, ResetTestPerson{FirstName: "Bob"}))
+	testReturn(t, L, `return q.first_name, getmetatable(q) == getmetatable(p)`, "Bob", "true")
+
+	config.Reset()
+	testReturn(t, L, `return pet.Name, pet.name`, "nil", "Rex")
+}
+
+func Test_config_reset_access(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	deny := false
+	config.CacheAccessPolicy = true
+	config.AccessPolicy = func(ctx AccessContext) error {
+		if deny {
+			return errors.New("person access denied")
+		}
+		return nil
+	}
+
+	L.SetGlobal("p", New(L, &ResetTestPerson{FirstName: "Tim"}))
+	testReturn(t, L, `return p.FirstName`, "Tim")
+
+	deny = true
+	testReturn(t, L, `return p.FirstName`, "Tim")
+
+	config.Reset()
+	testError(t, L, `return p.FirstName`, "person access denied")
+}
+
+func Test_config_freeze(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	config := GetConfig(L)
+	config.Freeze()
+	if !config.Frozen() {
+		t.Fatal("expecting frozen configuration")
+	}
+
+	for name, fn := range map[string]func(){
+		"Use":        func() { config.Use(func(next CallHandler) CallHandler { return next }) },
+		"BindType":   func() { config.BindType(reflect.TypeOf(ResetTestPet{})) },
+		"Invalidate": func() { config.Invalidate(reflect.TypeOf(ResetTestPet{})) },
+		"Reset":      func() { config.Reset() },
+	} {
+		func() {
+			defer func() {
+				if r, _ := recover().(string); !strings.Contains(r, name+" called on a frozen configuration") {
+					t.Fatalf("expecting %s to panic, got %v", name, r)
+				}
+			}()
+			fn()
+		}()
+	}
+
+	L.SetGlobal("pet", New(L, func() *ResetTestPet {
+		return &ResetTestPet{Name: "Rex"}
+	}))
+	L.SetGlobal("person", New(L, func() *ResetTestPerson {
+		return &ResetTestPerson{FirstName: "Tim"}
+	}))
+	testReturn(t, L, `return pet().Name`, "Rex")
+
+	// Settings changed after Freeze make the next use of the configuration
+	// panic.
+	config.AccessPolicy = func(ctx AccessContext) error {
+		return errors.New("access denied")
+	}
+	testError(t, L, `return pet().Name`, "configuration field AccessPolicy changed after Freeze")
+	config.AccessPolicy = nil
+	testReturn(t, L, `return person().FirstName`, "Tim")
+}
+
+func Test_config_freeze_fields(t *testing.T) {
+	// Every exported field of the configuration is checked.
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		config := newConfig()
+		config.Freeze()
+		value := reflect.ValueOf(config).Elem().Field(i)
+		switch value.Kind() {
+		case reflect.Func:
+			value.Set(reflect.MakeFunc(value.Type(), nil))
+		case reflect.Bool:
+			value.SetBool(!value.Bool())
+		case reflect.Int, reflect.Int64:
+			value.SetInt(value.Int() + 1)
+		default:
+			t.Fatalf("unexpected kind %s of field %s", value.Kind(), field.Name)
+		}
+		func() {
+			defer func() {
+				if r, _ := recover().(string); !strings.Contains(r, "field "+field.Name+" changed after Freeze") {
+					t.Fatalf("expecting a change of %s to panic, got %v", field.Name, r)
+				}
+			}()
+			config.settings()
+		}()
+	}
+}
@@ -13,6 +13,7 @@ func sliceIndex(L *lua.LState) int {
 
 	switch converted := key.(type) {
 	case lua.LNumber:
+		checkAccess(L, ref.Type(), "", AccessRead)
 		index := int(converted)
 		if index < 1 || index > ref.Len() {
 			L.ArgError(2, "index out of range")
@@ -56,6 +57,7 @@ func sliceNewIndex(L *lua.LState) int {
 	if index < 1 || index > ref.Len() {
 		L.ArgError(2, "index out of range")
 	}
+	checkAccess(L, ref.Type(), "", AccessWrite)
 	val := lValueToReflect(L, value, ref.Type().Elem(), nil)
 	if !val.IsValid() {
 		L.ArgError(3, "invalid value")
@@ -80,6 +82,7 @@ func sliceCall(L *lua.LState) int {
 	if isPtr {
 		L.RaiseError("invalid operation on slice pointer")
 	}
+	checkAccess(L, ref.Type(), "", AccessIterate)
 
 	i := 0
 	fn := func(L *lua.LState) int {
@@ -101,6 +104,11 @@ func sliceEq(L *lua.LState) int {
 	ref1, _, _, isPtr1 := check(L, 1, reflect.Slice)
 	ref2, _, _, isPtr2 := check(L, 2, reflect.Slice)
 
+	if equal, ok := customEqual(L, ref1, ref2); ok {
+		L.Push(lua.LBool(equal))
+		return 1
+	}
+
 	if isPtr1 && isPtr2 {
 		L.Push(lua.LBool(ref1.Pointer() == ref2.Pointer()))
 		return 1
@@ -128,8 +136,15 @@ func sliceAppend(L *lua.LState) int {
 	if opts.Immutable {
 		L.RaiseError("invalid operation on immutable slice")
 	}
+	checkAccess(L, ref.Type(), "", AccessAppend)
 
 	hint := ref.Type().Elem()
+	length := ref.Len() + L.GetTop() - 1
+	config := GetConfig(L)
+	config.checkSliceLength(L, length)
+	if length > ref.Cap() {
+		config.allocate(L, length, hint.Size())
+	}
 	values := make([]reflect.Value, L.GetTop()-1)
 	for i := 2; i <= L.GetTop(); i++ {
 		value := lValueToReflect(L, L.Get(i), hint, nil)
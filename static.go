@@ -0,0 +1,90 @@
+package luar
+
+import (
+	"reflect"
+
+	"github.com/yuin/gopher-lua"
+)
+
+// StaticType holds accessors for the fields and methods of a struct type that
+// do not use reflection. It is usually generated by cmd/luar-gen and
+// registered with Config.RegisterStatic.
+//
+// Static accessors only replace the reflective access to a member; the names
+// of the members, the access policy, bindings and immutability are handled as
+// for other types. If an accessor cannot handle a value, it returns false and
+// the reflective path is used instead, so its behaviour must match that of
+// the reflective path for the values it does handle.
+type StaticType struct {
+	// The accessors of the fields of the type that are not promoted from
+	// embedded structs, by Go name.
+	Fields map[string]StaticField
+	// The callers of the methods of the type and of pointers to it, by Go
+	// name. Like fast paths (see RegisterFastPath), they are only used while
+	// the configuration has neither middlewares nor an access policy. The
+	// receiver is the first argument.
+	Methods map[string]FastPath
+}
+
+// StaticField reads and writes a field of a struct without reflection. value
+// is the Go value of the luar value that is indexed, which is either the
+// struct or a pointer to it.
+type StaticField struct {
+	// Get returns the field of value converted to a Lua value.
+	Get func(value interface{}) (lua.LValue, bool)
+	// Set assigns lv to the field of value.
+	Set func(value interface{}, lv lua.LValue) bool
+}
+
+// RegisterStatic registers static accessors for the type of sample, or for
+// its element type if sample is a pointer. Like bindings, static accessors
+// apply to the metatables that are built afterwards.
+//
+// RegisterStatic panics if the configuration is frozen.
+//
+// Example:
+//  claims.RegisterLuar(luar.GetConfig(L)) // generated by luar-gen
+func (c *Config) RegisterStatic(sample interface{}, static StaticType) {
+	c.mustNotBeFrozen("RegisterStatic")
+	t := reflect.TypeOf(sample)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if c.static == nil {
+		c.static = make(map[reflect.Type]*StaticType)
+	}
+	c.static[t] = &static
+}
+
+// staticField returns the static accessors of the field of the struct type t
+// with the given index, or nil.
+func (c *Config) staticField(t reflect.Type, index []int) *StaticField {
+	static := c.static[t]
+	if static == nil || len(index) != 1 {
+		return nil
+	}
+	if field, ok := static.Fields[t.Field(index[0]).Name]; ok {
+		return &field
+	}
+	return nil
+}
+
+// staticMethod returns the static caller of method, or nil.
+func (c *Config) staticMethod(method reflect.Method) FastPath {
+	t := method.Type.In(0)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if static := c.static[t]; static != nil {
+		return static.Methods[method.Name]
+	}
+	return nil
+}
+
+// userDataInterface returns the Go value of a luar userdata.
+func userDataInterface(ud *lua.LUserData) interface{} {
+	if refIface, ok := ud.Value.(*reflectedInterface); ok {
+		return refIface.Interface
+	}
+	return ud.Value
+}
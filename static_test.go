@@ -0,0 +1,140 @@
+package luar
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+)
+
+type StaticTestClaim struct {
+	ID     int
+	Status string
+	Amount float64 `luar:"total"`
+	Member *StaticTestMember
+}
+
+func (c *StaticTestClaim) Approve(note string) string {
+	c.Status = "approved"
+	return note
+}
+
+type StaticTestMember struct {
+	Name string
+}
+
+// staticTestClaim returns hand-written static accessors for StaticTestClaim,
+// as generated by cmd/luar-gen, that count their calls.
+func staticTestClaim(calls map[string]int) StaticType {
+	return StaticType{
+		Fields: map[string]StaticField{
+			"ID": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*StaticTestClaim)
+					if !ok {
+						return nil, false
+					}
+					calls["get ID"]++
+					return lua.LNumber(float64(v.ID)), true
+				},
+				Set: func(value interface{}, lv lua.LValue) bool {
+					v, ok := value.(*StaticTestClaim)
+					arg, isArg := lv.(lua.LNumber)
+					if !ok || !isArg {
+						return false
+					}
+					calls["set ID"]++
+					v.ID = int(int64(arg))
+					return true
+				},
+			},
+			"Amount": {
+				Get: func(value interface{}) (lua.LValue, bool) {
+					v, ok := value.(*StaticTestClaim)
+					if !ok {
+						return nil, false
+					}
+					calls["get Amount"]++
+					return lua.LNumber(v.Amount), true
+				},
+			},
+		},
+		Methods: map[string]FastPath{
+			"Approve": func(L *lua.LState, _ interface{}) (int, bool) {
+				value, _, _ := Unwrap(L.Get(1))
+				v, ok := value.(*StaticTestClaim)
+				arg0, ok0 := L.Get(2).(lua.LString)
+				if !ok || !ok0 || L.GetTop() != 2 {
+					return 0, false
+				}
+				calls["Approve"]++
+				L.Push(lua.LString(v.Approve(string(arg0))))
+				return 1, true
+			},
+		},
+	}
+}
+
+func Test_static(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	calls := map[string]int{}
+	GetConfig(L).RegisterStatic(StaticTestClaim{}, staticTestClaim(calls))
+
+	claim := &StaticTestClaim{ID: 12, Status: "open", Amount: 10, Member: &StaticTestMember{Name: "ana"}}
+	L.SetGlobal("claim", New(L, claim))
+
+	testReturn(t, L, `return claim.ID, claim.total, claim.Status, claim.Member.Name`, "12", "10", "open", "ana")
+	testReturn(t, L, `claim.ID = 13; claim.Status = "closed"; return claim.ID, claim.Status`, "13", "closed")
+	testReturn(t, L, `return claim:Approve("ok"), claim.Status`, "ok", "approved")
+
+	// Values that the accessors do not handle use reflection.
+	testReturn(t, L, `return claim:Approve(15)`, "15")
+	testError(t, L, `claim.ID = "14"`, "invalid value")
+
+	expected := map[string]int{"get ID": 2, "set ID": 1, "get Amount": 1, "Approve": 1}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expecting calls %v, got %v", expected, calls)
+	}
+
+	// Struct values are not addressable and use reflection.
+	L.SetGlobal("value", New(L, StaticTestClaim{ID: 20}))
+	testReturn(t, L, `return value.ID`, "20")
+	if calls["get ID"] != 2 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+}
+
+func Test_static_rules(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	calls := map[string]int{}
+	config := GetConfig(L)
+	config.RegisterStatic((*StaticTestClaim)(nil), staticTestClaim(calls))
+	Bind[StaticTestClaim](config).Field("ID").As("id").ReadOnly()
+
+	claim := &StaticTestClaim{ID: 12}
+	L.SetGlobal("claim", New(L, claim))
+	L.SetGlobal("frozen", New(L, claim, ReflectOptions{Immutable: true}))
+
+	testReturn(t, L, `return claim.id, frozen.id`, "12", "12")
+	testError(t, L, `claim.id = 13`, "cannot set read-only field id")
+	testError(t, L, `frozen:Approve("ok")`, "cannot call mutating method Approve on immutable object")
+
+	config.AccessPolicy = func(ctx AccessContext) error {
+		if ctx.Member == "ID" {
+			return errors.New("access denied")
+		}
+		return nil
+	}
+	testError(t, L, `return claim.id`, "access denied")
+	testReturn(t, L, `return claim:Approve("ok")`, "ok")
+
+	expected := map[string]int{"get ID": 2}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expecting calls %v, got %v", expected, calls)
+	}
+}
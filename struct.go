@@ -2,38 +2,150 @@ package luar
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/yuin/gopher-lua"
 )
 
+// structMember is the field and methods of a struct type that a Lua name
+// refers to.
+type structMember struct {
+	// The function of the name in the methods table, or nil.
+	Method lua.LValue
+	// The function of the name in the ptr_methods table, or nil.
+	PtrMethod lua.LValue
+	// The index of the field of the name in the fields table, or nil.
+	Index []int
+	// The Go name of the field.
+	FieldName string
+	// Set if the field was bound as read-only.
+	ReadOnly bool
+	// The static accessors of the field, or nil.
+	Static *StaticField
+}
+
+// structMembers maps the Lua names of the members of a struct type to the
+// members, so that __index and __newindex find a member with a single lookup.
+// It is the upvalue of both functions. The methods, ptr_methods and fields
+// tables of the metatable hold the same members.
+type structMembers struct {
+	config          *Config
+	members         map[string]structMember
+	caseInsensitive bool
+}
+
+func (s *structMembers) build(vtype reflect.Type, fields, methods, ptrMethods *lua.LTable) {
+	s.members = make(map[string]structMember)
+	s.caseInsensitive = s.config.settings().CaseInsensitiveNames
+	fields.ForEach(func(key, value lua.LValue) {
+		index := value.(*lua.LUserData).Value.([]int)
+		member := s.members[key.String()]
+		member.Index = index
+		member.FieldName = vtype.FieldByIndex(index).Name
+		member.ReadOnly = s.config.isReadOnlyField(vtype, index)
+		member.Static = s.config.staticField(vtype, index)
+		s.members[key.String()] = member
+	})
+	methods.ForEach(func(key, value lua.LValue) {
+		member := s.members[key.String()]
+		member.Method = value
+		s.members[key.String()] = member
+	})
+	ptrMethods.ForEach(func(key, value lua.LValue) {
+		member := s.members[key.String()]
+		member.PtrMethod = value
+		s.members[key.String()] = member
+	})
+}
+
+func (s *structMembers) lookup(name string) (structMember, bool) {
+	member, ok := s.members[name]
+	if !ok && s.caseInsensitive {
+		member, ok = s.members[strings.ToLower(name)]
+	}
+	return member, ok
+}
+
+// upvalueStructMembers returns the members of the running __index or
+// __newindex function of a struct metatable.
+func upvalueStructMembers(L *lua.LState) *structMembers {
+	members := L.Get(lua.UpvalueIndex(1)).(*lua.LUserData).Value.(*structMembers)
+	if len(members.config.stale) > 0 {
+		// Rebuilds the metatable, and updates members, if it was
+		// invalidated.
+		userDataMetatable(L, L.CheckUserData(1))
+	}
+	return members
+}
+
+// metatableStructMembers returns the members of a struct metatable, or nil
+// for other metatables.
+func metatableStructMembers(mt *lua.LTable) *structMembers {
+	fn, ok := mt.RawGetString("__index").(*lua.LFunction)
+	if !ok || len(fn.Upvalues) == 0 {
+		return nil
+	}
+	if ud, ok := fn.Upvalues[0].Value().(*lua.LUserData); ok {
+		members, _ := ud.Value.(*structMembers)
+		return members
+	}
+	return nil
+}
+
+// structField returns the field of v with the given index, avoiding
+// FieldByIndex for fields that are not promoted.
+func structField(v reflect.Value, index []int) reflect.Value {
+	if len(index) == 1 {
+		return v.Field(index[0])
+	}
+	return v.FieldByIndex(index)
+}
+
 func structIndex(L *lua.LState) int {
-	ref, opts, mt, isPtr := check(L, 1, reflect.Struct)
+	ref, opts, isPtr := checkValue(L, 1, reflect.Struct)
+	members := upvalueStructMembers(L)
 	key := L.CheckString(2)
 
-	if !isPtr {
-		if fn := mt.method(key); fn != nil {
-			L.Push(fn)
-			return 1
-		}
+	member, ok := members.lookup(key)
+	if !ok {
+		return 0
 	}
 
-	if fn := mt.ptrMethod(key); fn != nil {
-		if opts.Immutable {
-			L.RaiseError("cannot call pointer methods on immutable objects")
-		}
+	if !isPtr && member.Method != nil {
+		L.Push(member.Method)
+		return 1
+	}
 
-		L.Push(fn)
+	if member.PtrMethod != nil {
+		// Mutating methods of immutable values raise an error when they
+		// are called (see checkMethodPurity).
+		L.Push(member.PtrMethod)
 		return 1
 	}
 
-	ref = reflect.Indirect(ref)
-	index := mt.fieldIndex(key)
-	if index == nil {
+	if member.Index == nil {
 		return 0
 	}
-	field := ref.FieldByIndex(index)
+	ref = reflect.Indirect(ref)
+	members.config.checkAccess(L, ref.Type(), member.FieldName, AccessRead)
+	if member.Static != nil && member.Static.Get != nil {
+		if lv, ok := member.Static.Get(userDataInterface(L.CheckUserData(1))); ok {
+			L.Push(lv)
+			return 1
+		}
+	}
+	field := structField(ref, member.Index)
 	if !field.CanInterface() {
-		L.RaiseError("cannot interface field " + key)
+		L.RaiseError("cannot interface field %s", key)
+	}
+	if lv, ok := basicLValue(field); ok {
+		L.Push(lv)
+		return 1
+	}
+	if member.ReadOnly {
+		// Composite values of read-only fields must not be modified
+		// through the returned value either.
+		opts.Immutable = true
 	}
 
 	switch field.Kind() {
@@ -42,9 +154,10 @@ func structIndex(L *lua.LState) int {
 			// Initialize pointers on first access
 			if !field.IsValid() || field.IsNil() {
 				if !field.CanSet() {
-					L.RaiseError("cannot transparently create pointer field " + key)
+					L.RaiseError("cannot transparently create pointer field %s", key)
 				}
-				if opts.AutoPopulate {
+				if opts.AutoPopulate && !member.ReadOnly {
+					GetConfig(L).allocate(L, 1, field.Type().Elem().Size())
 					field.Set(reflect.New(field.Type().Elem()))
 				}
 			}
@@ -59,9 +172,10 @@ func structIndex(L *lua.LState) int {
 			// Initialize slices on first access
 			if !field.IsValid() || field.IsNil() {
 				if !field.CanSet() {
-					L.RaiseError("cannot transparently create slice " + key)
+					L.RaiseError("cannot transparently create slice %s", key)
 				}
-				if opts.AutoPopulate {
+				if opts.AutoPopulate && !member.ReadOnly {
+					GetConfig(L).allocate(L, 10, field.Type().Elem().Size())
 					field.Set(reflect.MakeSlice(field.Type(), 0, 10))
 				}
 			}
@@ -77,7 +191,8 @@ func structIndex(L *lua.LState) int {
 }
 
 func structNewIndex(L *lua.LState) int {
-	ref, opts, mt, isPtr := check(L, 1, reflect.Struct)
+	ref, opts, isPtr := checkValue(L, 1, reflect.Struct)
+	members := upvalueStructMembers(L)
 
 	if opts.Immutable {
 		L.RaiseError("invalid operation on immutable struct")
@@ -90,11 +205,18 @@ func structNewIndex(L *lua.LState) int {
 	key := L.CheckString(2)
 	value := L.CheckAny(3)
 
-	index := mt.fieldIndex(key)
-	if index == nil {
-		L.RaiseError("unknown field " + key)
+	member, _ := members.lookup(key)
+	if member.Index == nil {
+		L.RaiseError("unknown field %s", key)
+	}
+	if member.ReadOnly {
+		L.RaiseError("cannot set read-only field %s", key)
 	}
-	field := ref.FieldByIndex(index)
+	members.config.checkAccess(L, ref.Type(), member.FieldName, AccessWrite)
+	if member.Static != nil && member.Static.Set != nil && member.Static.Set(userDataInterface(L.CheckUserData(1)), value) {
+		return 0
+	}
+	field := structField(ref, member.Index)
 
 	if opts.TransparentPointers {
 		// With transparent pointers, we are going to get passed the new value
@@ -114,7 +236,7 @@ func structNewIndex(L *lua.LState) int {
 			if !field.CanSet() {
 				// Can happen if the field is on a struct reflected by
 				// value instead of by reference.
-				L.RaiseError("cannot set field " + key)
+				L.RaiseError("cannot set field %s", key)
 			}
 			field.Set(reflect.New(goValue.Type()))
 			field.Elem().Set(goValue)
@@ -123,7 +245,10 @@ func structNewIndex(L *lua.LState) int {
 	}
 
 	if !field.CanSet() {
-		L.RaiseError("cannot set field " + key)
+		L.RaiseError("cannot set field %s", key)
+	}
+	if setBasic(field, value) {
+		return 0
 	}
 	val := lValueToReflect(L, value, field.Type(), nil)
 	if !val.IsValid() {
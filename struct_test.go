@@ -313,7 +313,7 @@ func Test_struct_immutable_ptrfunc(t *testing.T) {
 
 	L.SetGlobal("p", New(L, &p, ReflectOptions{Immutable: true}))
 
-	testError(t, L, `p:IncreaseAge()`, "cannot call pointer methods on immutable objects")
+	testError(t, L, `p:IncreaseAge()`, "cannot call mutating method IncreaseAge on immutable object")
 }
 
 func Test_struct_immutable_access(t *testing.T) {
@@ -775,3 +775,118 @@ func Test_struct_differentreflectopts(t *testing.T) {
 	)
 	testReturn(t, L, `return -a.Str`, "hello")
 }
+
+func (a TestTransparentPtrAccessA) GetB() *TestTransparentPtrAccessB {
+	return a.B
+}
+
+func (b *TestTransparentPtrAccessB) SetStr(str string) {
+	b.Str = &str
+}
+
+func Test_struct_method_inheritsopts(t *testing.T) {
+	// Values returned from methods should inherit the ReflectOptions of the
+	// receiver
+	L := lua.NewState()
+	defer L.Close()
+
+	val := "hello"
+	a := TestTransparentPtrAccessA{B: &TestTransparentPtrAccessB{Str: &val}}
+
+	L.SetGlobal("a", New(L, a, ReflectOptions{TransparentPointers: true}))
+	L.SetGlobal("frozen", New(L, a, ReflectOptions{Immutable: true}))
+
+	testReturn(t, L, `return a:GetB().Str`, "hello")
+	testReturn(t, L, `return -frozen:GetB().Str`, "hello")
+	testError(t, L, `frozen:GetB().Str = nil`, "invalid operation on immutable struct")
+}
+
+func Test_struct_ptrmethod_keepsopts(t *testing.T) {
+	// Calling a pointer method on a struct reflected by value should not
+	// drop the ReflectOptions of the value
+	L := lua.NewState()
+	defer L.Close()
+
+	b := TestTransparentPtrAccessB{}
+
+	L.SetGlobal("b", New(L, b, ReflectOptions{TransparentPointers: true}))
+
+	testReturn(t, L, `b:SetStr("world"); return b.Str`, "world")
+}
+
+type TestStructCounter interface {
+	Count() int
+	Reset()
+}
+
+type TestStructPureMethods struct {
+	TestStructCounter
+	Values []string
+}
+
+func (TestStructPureMethods) LuarPureMethods() []string {
+	return []string{"Count", "First"}
+}
+
+func (s TestStructPureMethods) First() string {
+	return s.Values[0]
+}
+
+func (s TestStructPureMethods) Clear() {
+	for i := range s.Values {
+		s.Values[i] = ""
+	}
+}
+
+type testStructCounter int
+
+func (c *testStructCounter) Count() int {
+	return int(*c)
+}
+
+func (c *testStructCounter) Reset() {
+	*c = 0
+}
+
+func Test_struct_immutable_puremethods(t *testing.T) {
+	// Only methods listed by LuarPureMethods can be called on immutable
+	// structs, including methods promoted from embedded interfaces
+	L := lua.NewState()
+	defer L.Close()
+
+	counter := testStructCounter(3)
+	s := TestStructPureMethods{
+		TestStructCounter: &counter,
+		Values:            []string{"a", "b"},
+	}
+
+	L.SetGlobal("s", New(L, s, ReflectOptions{Immutable: true}))
+
+	testReturn(t, L, `return s:First()`, "a")
+	testReturn(t, L, `return s:Count()`, "3")
+	testError(t, L, `s:Clear()`, "cannot call mutating method Clear on immutable object")
+	testError(t, L, `s:Reset()`, "cannot call mutating method Reset on immutable object")
+	testError(t, L, `local reset = s.Reset; reset(s)`, "cannot call mutating method Reset on immutable object")
+}
+
+func Test_struct_immutable_ptr_puremethods(t *testing.T) {
+	// Pure methods can be called on immutable pointers, whose methods include
+	// the methods of the pointer type
+	L := lua.NewState()
+	defer L.Close()
+
+	counter := testStructCounter(3)
+	s := &TestStructPureMethods{
+		TestStructCounter: &counter,
+		Values:            []string{"a", "b"},
+	}
+
+	L.SetGlobal("s", New(L, s, ReflectOptions{Immutable: true}))
+	L.SetGlobal("p", New(L, &StructTestPerson{Name: "Tim"}, ReflectOptions{Immutable: true}))
+
+	testReturn(t, L, `return s:First(), s:Count()`, "a", "3")
+	testError(t, L, `s:Clear()`, "cannot call mutating method Clear on immutable object")
+	testError(t, L, `s:Reset()`, "cannot call mutating method Reset on immutable object")
+	testReturn(t, L, `return p:Hello()`, "Hello, Tim")
+	testError(t, L, `p:IncreaseAge()`, "cannot call mutating method IncreaseAge on immutable object")
+}
@@ -6,39 +6,61 @@ import (
 	"github.com/yuin/gopher-lua"
 )
 
-func checkType(L *lua.LState, idx int) reflect.Type {
+func checkType(L *lua.LState, idx int) (ref reflect.Type, opts ReflectOptions) {
 	ud := L.CheckUserData(idx)
-	ref, ok := ud.Value.(reflect.Type)
+	refIface, ok := ud.Value.(*reflectedInterface)
+	if ok {
+		ref, ok = refIface.Interface.(reflect.Type)
+		opts = refIface.Options
+	}
 	if !ok {
 		L.ArgError(idx, "expecting type")
 	}
-	return ref
+	return
 }
 
 func typeCall(L *lua.LState) int {
-	ref := checkType(L, 1)
+	ref, opts := checkType(L, 1)
+	config := GetConfig(L)
 
 	var value reflect.Value
 	switch ref.Kind() {
 	case reflect.Chan:
 		buffer := L.OptInt(2, 0)
+		if buffer < 0 {
+			L.ArgError(2, "negative buffer size")
+		}
+		config.checkChanBuffer(L, buffer)
+		config.allocate(L, buffer, ref.Elem().Size())
 		value = reflect.MakeChan(ref, buffer)
 	case reflect.Map:
 		value = reflect.MakeMap(ref)
 	case reflect.Slice:
 		length := L.OptInt(2, 0)
 		capacity := L.OptInt(3, length)
+		if length < 0 {
+			L.ArgError(2, "negative length")
+		}
+		if capacity < length {
+			L.ArgError(3, "capacity smaller than length")
+		}
+		config.checkSliceLength(L, capacity)
+		config.allocate(L, capacity, ref.Elem().Size())
 		value = reflect.MakeSlice(ref, length, capacity)
 	default:
+		if ref.Kind() == reflect.Array {
+			config.checkSliceLength(L, ref.Len())
+		}
+		config.allocate(L, 1, ref.Size())
 		value = reflect.New(ref)
 	}
-	L.Push(New(L, value.Interface()))
+	L.Push(New(L, value.Interface(), opts))
 	return 1
 }
 
 func typeEq(L *lua.LState) int {
-	type1 := checkType(L, 1)
-	type2 := checkType(L, 2)
+	type1, _ := checkType(L, 1)
+	type2, _ := checkType(L, 2)
 	L.Push(lua.LBool(type1 == type2))
 	return 1
 }
@@ -40,3 +40,12 @@ func Test_type(t *testing.T) {
 		t.Fatalf("expecting len(everyone) = 2, got %d", len(everyone))
 	}
 }
+
+func Test_type_reflectoptions(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.SetGlobal("Person", NewType(L, StructTestPerson{}, ReflectOptions{Immutable: true}))
+
+	testError(t, L, `p = Person(); p.Name = "John"`, "invalid operation on immutable struct")
+}
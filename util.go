@@ -10,6 +10,13 @@ import (
 )
 
 func check(L *lua.LState, idx int, kind reflect.Kind) (ref reflect.Value, opts ReflectOptions, mt *Metatable, isPtr bool) {
+	ref, opts, isPtr = checkValue(L, idx, kind)
+	mt = userDataMetatable(L, L.CheckUserData(idx))
+	return
+}
+
+// checkValue is like check, but does not return the metatable.
+func checkValue(L *lua.LState, idx int, kind reflect.Kind) (ref reflect.Value, opts ReflectOptions, isPtr bool) {
 	ud := L.CheckUserData(idx)
 	refIface, ok := ud.Value.(*reflectedInterface)
 	if ok {
@@ -26,7 +33,6 @@ func check(L *lua.LState, idx int, kind reflect.Kind) (ref reflect.Value, opts R
 		}
 		isPtr = true
 	}
-	mt = &Metatable{LTable: ud.Metatable.(*lua.LTable)}
 	return
 }
 
@@ -35,19 +41,40 @@ func tostring(L *lua.LState) int {
 	value := ud.Value
 	if refIface, ok := value.(*reflectedInterface); ok {
 		value = refIface.Interface
+		if t := refIface.IfaceType; t != nil && !t.Implements(refTypeStringer) {
+			// Only the methods of the interface type are exposed, so the
+			// value is formatted with Error even if it is a Stringer.
+			if err, ok := value.(error); ok && t.Implements(refTypeError) {
+				L.Push(lua.LString(err.Error()))
+				return 1
+			}
+			value = nil
+		}
 	}
 	if stringer, ok := value.(fmt.Stringer); ok {
 		L.Push(lua.LString(stringer.String()))
-	} else {
+	} else if err, ok := value.(error); ok {
+		L.Push(lua.LString(err.Error()))
+	} else if value == nil {
 		L.Push(lua.LString(fmt.Sprintf("userdata (luar): %p", ud)))
+	} else if fn := GetConfig(L).settings().Tostring; fn != nil {
+		L.Push(lua.LString(fn(L, value)))
+	} else {
+		L.Push(lua.LString(summary(L, value)))
 	}
 	return 1
 }
 
+func opaqueTostring(L *lua.LState) int {
+	ud := L.CheckUserData(1)
+	L.Push(lua.LString(fmt.Sprintf("userdata (luar opaque): %p", ud)))
+	return 1
+}
+
 func eq(L *lua.LState) int {
-	ud1 := L.CheckUserData(1).Value
-	ud2 := L.CheckUserData(2).Value
-	L.Push(lua.LBool(reflect.DeepEqual(ud1, ud2)))
+	ud1 := L.CheckUserData(1)
+	ud2 := L.CheckUserData(2)
+	L.Push(lua.LBool(valuesEqual(L, ud1, ud2, EqualityDeep)))
 	return 1
 }
 